@@ -0,0 +1,145 @@
+package engoBox2dSystem
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+)
+
+// The baseline for these is in BENCHMARKS.md. Run them with
+//
+//	go test -run NONE -bench . -benchmem
+
+var benchmarkSizes = []int{100, 1000, 10000}
+
+// newBenchmarkBoxes adds n 10x10 dynamic boxes in a grid. If touching is true
+// they're stacked in pairs that overlap, so there are n/2 contacts each step.
+func newBenchmarkBoxes(n int, touching bool) []physicsEntity {
+	entities := make([]physicsEntity, n)
+	for i := range entities {
+		basic := ecs.NewBasic()
+		space := &common.SpaceComponent{
+			Position: engo.Point{X: float32(i%100) * 20, Y: float32(i/100) * 20},
+			Width:    10,
+			Height:   10,
+		}
+		if touching && i%2 == 1 {
+			space.Position = entities[i-1].Position
+			space.Position.X += 8
+		}
+		bodyDef := box2d.NewB2BodyDef()
+		bodyDef.Type = box2d.B2BodyType.B2_dynamicBody
+		bodyDef.Position = Conv.ToBox2d2Vec(space.Center())
+		bodyDef.AllowSleep = false
+		body := World.CreateBody(bodyDef)
+		shape := box2d.NewB2PolygonShape()
+		shape.SetAsBox(Conv.PxToMeters(space.Width/2), Conv.PxToMeters(space.Height/2))
+		body.CreateFixture(shape, 1)
+		entities[i] = physicsEntity{&basic, space, &Box2dComponent{Body: body}}
+	}
+	return entities
+}
+
+func BenchmarkPhysicsSystemUpdate(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			newTestWorld(b, 0)
+			phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+			for _, e := range newBenchmarkBoxes(n, false) {
+				phys.Add(e.BasicEntity, e.SpaceComponent, e.Box2dComponent)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				phys.Update(1.0 / 60.0)
+			}
+		})
+	}
+}
+
+func BenchmarkMouseSystemUpdate(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			newTestWorld(b, 0)
+			engo.Run(engo.RunOptions{
+				Width:        100,
+				Height:       100,
+				NoRun:        true,
+				HeadlessMode: true,
+			}, &MouseTestScene{n})
+			// over the last entity, so every fixture is tested
+			engo.Input.Mouse.X = float32((n-1)*20 + 5)
+			engo.Input.Mouse.Y = 5
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sys.Update(1.0 / 60.0)
+			}
+		})
+	}
+}
+
+func BenchmarkCollisionSystemDispatch(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			newTestWorld(b, 0)
+			var received int
+			newTestMailbox(b)
+			for _, msg := range []string{"CollisionStartMessage", "CollisionEndMessage", "PreSolveMessage", "PostSolveMessage"} {
+				engo.Mailbox.Listen(msg, func(engo.Message) { received++ })
+			}
+			coll := &CollisionSystem{}
+			coll.New(nil)
+			phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+			for _, e := range newBenchmarkBoxes(n, true) {
+				coll.Add(e.BasicEntity, e.SpaceComponent, e.Box2dComponent)
+				phys.Add(e.BasicEntity, e.SpaceComponent, e.Box2dComponent)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				phys.Update(1.0 / 60.0)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(received)/float64(b.N), "msgs/op")
+		})
+	}
+}
+
+// BenchmarkSystemRemove removes every entity from the physics, collision, and
+// mouse systems, one at a time in the order they were added.
+func BenchmarkSystemRemove(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			newTestWorld(b, 0)
+			entities := newBenchmarkBoxes(n, false)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				phys := &PhysicsSystem{}
+				coll := &CollisionSystem{}
+				mouse := &MouseSystem{}
+				for _, e := range entities {
+					phys.Add(e.BasicEntity, e.SpaceComponent, e.Box2dComponent)
+					coll.Add(e.BasicEntity, e.SpaceComponent, e.Box2dComponent)
+					mouse.Add(e.BasicEntity, &MouseComponent{}, e.SpaceComponent, nil, e.Box2dComponent)
+				}
+				b.StartTimer()
+				for _, e := range entities {
+					phys.Remove(*e.BasicEntity)
+					coll.Remove(*e.BasicEntity)
+					mouse.Remove(*e.BasicEntity)
+				}
+			}
+		})
+	}
+}
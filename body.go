@@ -22,6 +22,8 @@ func (b *Box2dComponent) DestroyBody() {
 // during a simulation step.
 func removeBodies() {
 	for _, bod := range listOfBodiesToRemove {
+		sceneData.Forget(bod)
+		delete(ignoringEffectors, bod)
 		World.DestroyBody(bod)
 	}
 	listOfBodiesToRemove = make([]*box2d.B2Body, 0)
@@ -0,0 +1,262 @@
+package engoBox2dSystem
+
+import (
+	"math"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// CharacterControllerSystemPriority makes sure the characters are moved before
+// the PhysicsSystem steps.
+const CharacterControllerSystemPriority = 50
+
+// CharacterControllerComponent moves a body like a platformer character. Set
+// Move and Jump from your input each frame, and the CharacterControllerSystem
+// sets the body's velocity. The body should have fixed rotation. Speeds are in
+// pixels per second, and "up" is against the World's gravity.
+//
+// Any tuning left at zero uses its default.
+type CharacterControllerComponent struct {
+	// Move is how hard the character is trying to move, from -1 (left) to 1
+	// (right).
+	Move float32
+	// Jump is true while the jump button is held down.
+	Jump bool
+
+	// MaxSpeed is the fastest the character runs. Defaults to 300.
+	MaxSpeed float32
+	// Acceleration is how quickly the character gets up to speed or stops on
+	// the ground, in pixels per second squared. Defaults to 3000.
+	Acceleration float32
+	// AirControl is the fraction of Acceleration the character has in the air.
+	// Defaults to 0.5.
+	AirControl float32
+	// NoAirControl stops the character from steering in the air at all.
+	NoAirControl bool
+	// JumpSpeed is how fast the character leaves the ground when jumping.
+	// Defaults to 500.
+	JumpSpeed float32
+	// JumpCut is what the upward speed is multiplied by when Jump is let go
+	// early, so a tap jumps lower than holding. Defaults to 0.5; set it to 1 for
+	// jumps that are always the same height.
+	JumpCut float32
+	// MaxSlope is the steepest ground, in degrees, that the character can stand
+	// on. Steeper ground is treated like a wall. Defaults to 50.
+	MaxSlope float32
+	// CoyoteTime is how long after walking off a ledge the character can still
+	// jump, in seconds. Defaults to 0.1.
+	CoyoteTime float32
+	// JumpBuffer is how long before landing a jump press is remembered, in
+	// seconds. Defaults to 0.1.
+	JumpBuffer float32
+
+	// OnGround is true when the character is standing on something.
+	OnGround bool
+	// Ground is the body the character is standing on, and GroundNormal the
+	// normal of the ground, pointing away from it.
+	Ground       *box2d.B2Body
+	GroundNormal engo.Point
+	// Wall is -1 if the character is touching a wall to its left, 1 if it's to
+	// its right, and 0 otherwise.
+	Wall int
+	// Jumped is true on the frame the character jumped.
+	Jumped bool
+
+	sinceGround, sinceJump float32
+	jumpHeld, jumping      bool
+}
+
+type characterEntity struct {
+	*ecs.BasicEntity
+	*CharacterControllerComponent
+	*Box2dComponent
+}
+
+// CharacterControllerSystem moves the bodies of entities with a
+// CharacterControllerComponent.
+type CharacterControllerSystem struct {
+	entities []characterEntity
+}
+
+// Priority implements the ecs.Prioritizer interface.
+func (c *CharacterControllerSystem) Priority() int { return CharacterControllerSystemPriority }
+
+// Add adds the entity to the system. The friction of the body's fixtures is
+// set to zero, since the system takes care of speeding up and stopping, and
+// friction would hold the character against walls.
+func (c *CharacterControllerSystem) Add(basic *ecs.BasicEntity, character *CharacterControllerComponent, box *Box2dComponent) {
+	for f := box.Body.GetFixtureList(); f != nil; f = f.GetNext() {
+		f.SetFriction(0)
+	}
+	for ce := box.Body.GetContactList(); ce != nil; ce = ce.Next {
+		ce.Contact.ResetFriction()
+	}
+	// nothing has happened yet, so don't let the timers start out in range
+	character.sinceGround = float32(math.Inf(1))
+	character.sinceJump = float32(math.Inf(1))
+	c.entities = append(c.entities, characterEntity{basic, character, box})
+}
+
+// Remove removes the entity from the system.
+func (c *CharacterControllerSystem) Remove(basic ecs.BasicEntity) {
+	idx := -1
+	for index, e := range c.entities {
+		if e.BasicEntity.ID() == basic.ID() {
+			idx = index
+			break
+		}
+	}
+	if idx >= 0 {
+		c.entities = append(c.entities[:idx], c.entities[idx+1:]...)
+	}
+}
+
+// Update looks at what each character is touching and sets its velocity.
+func (c *CharacterControllerSystem) Update(dt float32) {
+	up := World.GetGravity()
+	if up.Normalize() == 0 {
+		up = box2d.B2Vec2{X: 0, Y: -1}
+	} else {
+		up = box2d.B2Vec2{X: -up.X, Y: -up.Y}
+	}
+	right := box2d.B2Vec2{X: -up.Y, Y: up.X}
+
+	for _, e := range c.entities {
+		c.updateCharacter(e, float64(dt), up, right)
+	}
+}
+
+func (c *CharacterControllerSystem) updateCharacter(e characterEntity, dt float64, up, right box2d.B2Vec2) {
+	ch := e.CharacterControllerComponent
+	body := e.Body
+	maxSpeed := Conv.PxToMeters(defaultFloat(ch.MaxSpeed, 300))
+	accel := Conv.PxToMeters(defaultFloat(ch.Acceleration, 3000))
+	airControl := float64(defaultFloat(ch.AirControl, 0.5))
+	if ch.NoAirControl {
+		airControl = 0
+	}
+	jumpSpeed := Conv.PxToMeters(defaultFloat(ch.JumpSpeed, 500))
+	jumpCut := float64(defaultFloat(ch.JumpCut, 0.5))
+	minGroundDot := math.Cos(float64(Conv.DegToRad(defaultFloat(ch.MaxSlope, 50))))
+	coyoteTime := defaultFloat(ch.CoyoteTime, 0.1)
+	jumpBuffer := defaultFloat(ch.JumpBuffer, 0.1)
+
+	// find the ground and walls from the contacts of the last step
+	ch.OnGround, ch.Ground, ch.GroundNormal, ch.Wall, ch.Jumped = false, nil, engo.Point{}, 0, false
+	var normal, groundPoint box2d.B2Vec2
+	bestDot := minGroundDot
+	// right after jumping the character can still be touching the ground
+	rising := ch.jumping && box2d.B2Vec2Dot(body.GetLinearVelocity(), up) > 0
+	for ce := body.GetContactList(); ce != nil; ce = ce.Next {
+		contact := ce.Contact
+		if !contact.IsTouching() || !contact.IsEnabled() || contact.GetFixtureA().IsSensor() || contact.GetFixtureB().IsSensor() {
+			continue
+		}
+		var wm box2d.B2WorldManifold
+		contact.GetWorldManifold(&wm)
+		// the manifold's normal points from A to B, we want it pointing at the character
+		n := wm.Normal
+		if contact.GetFixtureA().GetBody() == body {
+			n = box2d.B2Vec2{X: -n.X, Y: -n.Y}
+		}
+		dot := box2d.B2Vec2Dot(n, up)
+		switch {
+		case dot >= bestDot && !rising:
+			bestDot = dot
+			ch.OnGround = true
+			ch.Ground = ce.Other
+			normal = n
+			groundPoint = wm.Points[0]
+		case dot < minGroundDot && dot > -minGroundDot:
+			// too steep to stand on, so it's a wall
+			if box2d.B2Vec2Dot(n, right) > 0 {
+				ch.Wall = -1
+			} else {
+				ch.Wall = 1
+			}
+		}
+	}
+
+	// when standing on something, move relative to it
+	var groundVelocity box2d.B2Vec2
+	if ch.OnGround {
+		ch.GroundNormal = engo.Point{X: float32(normal.X), Y: float32(normal.Y)}
+		groundVelocity = ch.Ground.GetLinearVelocityFromWorldPoint(groundPoint)
+		ch.sinceGround = 0
+	} else {
+		ch.sinceGround += float32(dt)
+	}
+
+	if ch.Jump && !ch.jumpHeld {
+		ch.sinceJump = 0
+	} else {
+		ch.sinceJump += float32(dt)
+	}
+	ch.jumpHeld = ch.Jump
+
+	v := box2d.B2Vec2Sub(body.GetLinearVelocity(), groundVelocity)
+	vUp := box2d.B2Vec2Dot(v, up)
+
+	if ch.jumping && (!ch.Jump || vUp <= 0) {
+		if vUp > 0 {
+			vUp *= jumpCut
+		}
+		ch.jumping = false
+	}
+
+	if ch.sinceJump <= jumpBuffer && ch.sinceGround <= coyoteTime {
+		vUp = jumpSpeed
+		ch.Jumped = true
+		ch.jumping = true
+		ch.OnGround = false
+		ch.sinceGround = float32(math.Inf(1))
+		ch.sinceJump = float32(math.Inf(1))
+	}
+
+	target := float64(ch.Move) * maxSpeed
+
+	// the direction to run in, along the ground if standing on it
+	along := right
+	if ch.OnGround {
+		along = box2d.B2Vec2{X: -normal.Y, Y: normal.X}
+	}
+	speed := box2d.B2Vec2Dot(v, along)
+	rate := accel
+	if !ch.OnGround {
+		rate *= math.Max(airControl, 0)
+	}
+	speed = approach(speed, target, rate*dt)
+	if ch.Wall != 0 && !ch.OnGround && speed*float64(ch.Wall) > 0 {
+		// don't keep running into a wall in the air, the solver would only
+		// have to undo it every step
+		speed = 0
+	}
+
+	if ch.OnGround {
+		v = box2d.B2Vec2MulScalar(speed, along)
+		// cancel the part of gravity that would slide the character down a slope
+		g := box2d.B2Vec2MulScalar(body.GetMass()*body.GetGravityScale(), World.GetGravity())
+		body.ApplyForceToCenter(box2d.B2Vec2MulScalar(-box2d.B2Vec2Dot(g, along), along), true)
+	} else {
+		v = box2d.B2Vec2Add(box2d.B2Vec2MulScalar(speed, right), box2d.B2Vec2MulScalar(vUp, up))
+	}
+	body.SetLinearVelocity(box2d.B2Vec2Add(v, groundVelocity))
+}
+
+// approach moves from towards to by at most step.
+func approach(from, to, step float64) float64 {
+	if from < to {
+		return math.Min(from+step, to)
+	}
+	return math.Max(from-step, to)
+}
+
+func defaultFloat(v, def float32) float32 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,284 @@
+package engoBox2dSystem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+func TestCharacterControllerRun(t *testing.T) {
+	newTestWorld(t, 10)
+	sys := &CharacterControllerSystem{}
+
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 350}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+	body.SetFixedRotation(true)
+	ch := &CharacterControllerComponent{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, ch, &Box2dComponent{Body: body})
+	ground := addStatic(engo.Point{X: 0, Y: 410}, 2000, 20, 0)
+
+	stepFrames(60, sys.Update, stepWorld)
+	if !ch.OnGround || ch.Ground != ground {
+		t.Fatalf("character did not land on the ground")
+	}
+	if !engo.FloatEqual(ch.GroundNormal.Y, -1) {
+		t.Errorf("ground normal should point up, got: %v", ch.GroundNormal)
+	}
+
+	ch.Move = 1
+	stepFrames(3, sys.Update, stepWorld)
+	if v := Conv.ToEngoPoint(body.GetLinearVelocity()).X; v <= 0 || v >= 300 {
+		t.Errorf("character should still be speeding up, got: %v", v)
+	}
+	stepFrames(60, sys.Update, stepWorld)
+	if v := Conv.ToEngoPoint(body.GetLinearVelocity()).X; !engo.FloatEqual(v, 300) {
+		t.Errorf("character should be at max speed, want: %v, got: %v", 300, v)
+	}
+
+	ch.Move = 0
+	stepFrames(30, sys.Update, stepWorld)
+	if v := Conv.ToEngoPoint(body.GetLinearVelocity()).X; !engo.FloatEqual(v, 0) {
+		t.Errorf("character should have stopped, got: %v", v)
+	}
+}
+
+func TestCharacterControllerJump(t *testing.T) {
+	apex := func(hold int) float32 {
+		newTestWorld(t, 10)
+		sys := &CharacterControllerSystem{}
+		body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 380}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+		body.SetFixedRotation(true)
+		ch := &CharacterControllerComponent{}
+		basic := ecs.NewBasic()
+		sys.Add(&basic, ch, &Box2dComponent{Body: body})
+		addStatic(engo.Point{X: 0, Y: 410}, 2000, 20, 0)
+
+		stepFrames(30, sys.Update, stepWorld)
+		start := Conv.ToEngoPoint(body.GetPosition()).Y
+
+		ch.Jump = true
+		sys.Update(1.0 / 60.0)
+		if !ch.Jumped {
+			t.Fatalf("character did not jump")
+		}
+		if v := Conv.ToEngoPoint(body.GetLinearVelocity()).Y; !engo.FloatEqual(v, -500) {
+			t.Errorf("wrong jump speed, want: %v, got: %v", -500, v)
+		}
+		stepWorld(1.0 / 60.0)
+
+		top := start
+		for i := 1; i < 120; i++ {
+			if i == hold {
+				ch.Jump = false
+			}
+			stepFrames(1, sys.Update, stepWorld)
+			if ch.Jumped {
+				t.Fatalf("character jumped again in the air")
+			}
+			top = float32(math.Min(float64(top), float64(Conv.ToEngoPoint(body.GetPosition()).Y)))
+		}
+		return start - top
+	}
+
+	held, tapped := apex(120), apex(3)
+	if tapped >= held {
+		t.Errorf("a tapped jump should be lower than a held one, tapped: %v, held: %v", tapped, held)
+	}
+
+	// pressing again in the air doesn't jump
+	newTestWorld(t, 10)
+	sys := &CharacterControllerSystem{}
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 100}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+	body.SetFixedRotation(true)
+	ch := &CharacterControllerComponent{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, ch, &Box2dComponent{Body: body})
+	addStatic(engo.Point{X: 0, Y: 410}, 2000, 20, 0)
+
+	stepFrames(5, sys.Update, stepWorld)
+	ch.Jump = true
+	stepFrames(1, sys.Update, stepWorld)
+	if ch.Jumped || body.GetLinearVelocity().Y < 0 {
+		t.Errorf("character jumped in the air")
+	}
+
+	ch.Jump = false
+	ch.Move = 1
+	ch.NoAirControl = true
+	stepFrames(5, sys.Update, stepWorld)
+	if v := body.GetLinearVelocity().X; v != 0 {
+		t.Errorf("character without air control steered in the air, got: %v", v)
+	}
+	ch.NoAirControl = false
+	stepFrames(1, sys.Update, stepWorld)
+	if v := body.GetLinearVelocity().X; v <= 0 {
+		t.Errorf("character with air control should steer in the air, got: %v", v)
+	}
+}
+
+// TestCharacterControllerTiming walks off a ledge and jumps a little late, and
+// presses jump a little before landing.
+func TestCharacterControllerTiming(t *testing.T) {
+	offLedge := func(late int) bool {
+		newTestWorld(t, 10)
+		sys := &CharacterControllerSystem{}
+		body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 80, Y: 380}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+		body.SetFixedRotation(true)
+		ch := &CharacterControllerComponent{}
+		basic := ecs.NewBasic()
+		sys.Add(&basic, ch, &Box2dComponent{Body: body})
+		addStatic(engo.Point{X: 0, Y: 410}, 200, 20, 0)
+
+		stepFrames(30, sys.Update, stepWorld)
+		ch.Move = 1
+		for ch.OnGround {
+			stepFrames(1, sys.Update, stepWorld)
+		}
+		stepFrames(late, sys.Update, stepWorld)
+		ch.Jump = true
+		stepFrames(1, sys.Update, stepWorld)
+		return ch.Jumped
+	}
+	if !offLedge(3) {
+		t.Errorf("character should be able to jump just after leaving a ledge")
+	}
+	if offLedge(12) {
+		t.Errorf("character should not be able to jump long after leaving a ledge")
+	}
+
+	// the frames it takes to land from the same height
+	newTestWorld(t, 10)
+	sys := &CharacterControllerSystem{}
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 200}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+	body.SetFixedRotation(true)
+	ch := &CharacterControllerComponent{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, ch, &Box2dComponent{Body: body})
+	addStatic(engo.Point{X: 0, Y: 410}, 2000, 20, 0)
+	frames := 0
+	for !ch.OnGround {
+		stepFrames(1, sys.Update, stepWorld)
+		frames++
+	}
+
+	landing := func(early int) bool {
+		newTestWorld(t, 10)
+		sys := &CharacterControllerSystem{}
+		body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 200}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+		body.SetFixedRotation(true)
+		ch := &CharacterControllerComponent{}
+		basic := ecs.NewBasic()
+		sys.Add(&basic, ch, &Box2dComponent{Body: body})
+		addStatic(engo.Point{X: 0, Y: 410}, 2000, 20, 0)
+
+		stepFrames(frames-early, sys.Update, stepWorld)
+		ch.Jump = true
+		for i := 0; i < early+1; i++ {
+			stepFrames(1, sys.Update, stepWorld)
+			if ch.Jumped {
+				return true
+			}
+		}
+		return false
+	}
+	if !landing(3) {
+		t.Errorf("a jump pressed just before landing should happen on landing")
+	}
+	if landing(12) {
+		t.Errorf("a jump pressed long before landing should be forgotten")
+	}
+}
+
+func TestCharacterControllerSlopes(t *testing.T) {
+	newTestWorld(t, 10)
+	sys := &CharacterControllerSystem{}
+
+	// standing still on a gentle slope doesn't slide
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 330}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+	body.SetFixedRotation(true)
+	ch := &CharacterControllerComponent{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, ch, &Box2dComponent{Body: body})
+	addStatic(engo.Point{X: 0, Y: 400}, 1000, 20, 30)
+
+	stepFrames(60, sys.Update, stepWorld)
+	if !ch.OnGround {
+		t.Fatalf("character should be able to stand on a 30 degree slope")
+	}
+	start := Conv.ToEngoPoint(body.GetPosition())
+	stepFrames(120, sys.Update, stepWorld)
+	end := Conv.ToEngoPoint(body.GetPosition())
+	if d := end.PointDistance(start); d > 1 {
+		t.Errorf("character slid down the slope, moved: %v", d)
+	}
+
+	// too steep to stand on
+	newTestWorld(t, 10)
+	sys = &CharacterControllerSystem{}
+	body = addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 300}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+	body.SetFixedRotation(true)
+	ch = &CharacterControllerComponent{}
+	basic = ecs.NewBasic()
+	sys.Add(&basic, ch, &Box2dComponent{Body: body})
+	addStatic(engo.Point{X: 30, Y: 400}, 1000, 20, 70)
+
+	stepFrames(60, sys.Update, stepWorld)
+	if ch.OnGround {
+		t.Errorf("character should not be able to stand on a 70 degree slope")
+	}
+	if v := body.GetLinearVelocity(); v.Y <= 0 {
+		t.Errorf("character should slide down a steep slope, got: %v", v)
+	}
+}
+
+func TestCharacterControllerWall(t *testing.T) {
+	newTestWorld(t, 10)
+	sys := &CharacterControllerSystem{}
+
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 0}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+	body.SetFixedRotation(true)
+	ch := &CharacterControllerComponent{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, ch, &Box2dComponent{Body: body})
+	addStatic(engo.Point{X: 20, Y: 0}, 20, 1000, 0)
+
+	ch.Move = 1
+	stepFrames(30, sys.Update, stepWorld)
+	if ch.Wall != 1 {
+		t.Errorf("character should be against a wall to its right, got: %d", ch.Wall)
+	}
+	// free fall for half a second is about 2.5 m/s
+	if v := Conv.ToEngoPoint(body.GetLinearVelocity()).Y; v < Conv.MetersToPx(4.5) {
+		t.Errorf("character stuck to the wall, falling at: %v", v)
+	}
+}
+
+func TestCharacterControllerPlatform(t *testing.T) {
+	newTestWorld(t, 10)
+	sys := &CharacterControllerSystem{}
+
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 370}, 0, testBox(20, 40), box2d.B2FixtureDef{Density: 1, Friction: 0.5})
+	body.SetFixedRotation(true)
+	ch := &CharacterControllerComponent{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, ch, &Box2dComponent{Body: body})
+	platform := addTestBody(box2d.B2BodyType.B2_kinematicBody, engo.Point{X: 0, Y: 400}, 0, testBox(200, 20), box2d.B2FixtureDef{})
+	stepFrames(30, sys.Update, stepWorld)
+
+	platform.SetLinearVelocity(box2d.B2Vec2{X: 2, Y: -1})
+	stepFrames(60, sys.Update, stepWorld)
+	if ch.Ground != platform {
+		t.Fatalf("character should be standing on the platform")
+	}
+	v := body.GetLinearVelocity()
+	if math.Abs(v.X-2) > 1e-6 || math.Abs(v.Y+1) > 1e-6 {
+		t.Errorf("character should ride the platform, want: (2, -1), got: %v", v)
+	}
+	if d := body.GetPosition().X - platform.GetPosition().X; math.Abs(d) > 0.01 {
+		t.Errorf("character slid on the platform, offset: %v", d)
+	}
+}
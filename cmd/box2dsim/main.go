@@ -0,0 +1,231 @@
+// Command box2dsim loads a RUBE json scene, steps it without a window, and
+// writes out how the bodies moved. It's handy for checking that a level is
+// stable from a script, or for timing the physics on a machine with no GPU.
+//
+// Usage:
+//
+//	box2dsim [flags] scene.json
+//
+// Positions are in the scene's units, the same as in the editor. It only uses
+// box2d and the rube package, not engo, so it builds without cgo and runs
+// without a display or sound card.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ByteArena/box2d"
+
+	"github.com/Noofbiz/engoBox2dSystem/rube"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// BodyState is the state of a body at one step.
+type BodyState struct {
+	Step            int     `json:"step"`
+	Body            string  `json:"body"`
+	X               float64 `json:"x"`
+	Y               float64 `json:"y"`
+	Angle           float64 `json:"angle"`
+	VX              float64 `json:"vx"`
+	VY              float64 `json:"vy"`
+	AngularVelocity float64 `json:"angularVelocity"`
+	Awake           bool    `json:"awake"`
+	// Moved is how far the body is from where it started
+	Moved float64 `json:"moved"`
+}
+
+// ContactEvent is a pair of bodies starting or stopping touching.
+type ContactEvent struct {
+	Step  int    `json:"step"`
+	Event string `json:"event"`
+	BodyA string `json:"bodyA"`
+	BodyB string `json:"bodyB"`
+}
+
+// Result is everything written out by the json format.
+type Result struct {
+	Steps        int            `json:"steps"`
+	Dt           float64        `json:"dt"`
+	Elapsed      time.Duration  `json:"elapsedNanoseconds"`
+	Trajectories []BodyState    `json:"trajectories"`
+	Contacts     []ContactEvent `json:"contacts"`
+	Final        []BodyState    `json:"final"`
+}
+
+type simulation struct {
+	names  map[*box2d.B2Body]string
+	starts map[*box2d.B2Body]box2d.B2Vec2
+	step   int
+	result Result
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("box2dsim", flag.ContinueOnError)
+	steps := flags.Int("steps", 600, "number of steps to simulate")
+	dt := flags.Float64("dt", 0, "seconds per step, defaults to the scene's steps per second")
+	every := flags.Int("every", 1, "record the trajectories every this many steps, 0 to leave them out")
+	format := flags.String("format", "json", "output format, json or csv")
+	out := flags.String("o", "", "file to write to, defaults to stdout")
+	velocityIterations := flags.Int("velocity-iterations", 0, "velocity iterations, defaults to the scene's")
+	positionIterations := flags.Int("position-iterations", 0, "position iterations, defaults to the scene's")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: box2dsim [flags] scene.json")
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	// start from an empty world, so each run gives the same results
+	world := box2d.MakeB2World(box2d.B2Vec2{})
+	scene, err := rube.NewLoader(&world).Load(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	if *dt <= 0 {
+		*dt = 1.0 / 60.0
+		if scene.StepsPerSecond > 0 {
+			*dt = 1 / float64(scene.StepsPerSecond)
+		}
+	}
+	if *velocityIterations <= 0 {
+		*velocityIterations = scene.VelocityIterations
+	}
+	if *positionIterations <= 0 {
+		*positionIterations = scene.PositionIterations
+	}
+
+	sim := &simulation{
+		names:  make(map[*box2d.B2Body]string),
+		starts: make(map[*box2d.B2Body]box2d.B2Vec2),
+		result: Result{Steps: *steps, Dt: *dt},
+	}
+	for i, e := range scene.Bodies {
+		name := e.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		sim.names[e.Body] = name
+		sim.starts[e.Body] = e.Body.GetPosition()
+	}
+	world.SetContactListener(sim)
+
+	start := time.Now()
+	for sim.step = 1; sim.step <= *steps; sim.step++ {
+		world.Step(*dt, *velocityIterations, *positionIterations)
+		if *every > 0 && sim.step%*every == 0 {
+			sim.result.Trajectories = append(sim.result.Trajectories, sim.states(scene)...)
+		}
+	}
+	sim.result.Elapsed = time.Since(start)
+	sim.step = *steps
+	sim.result.Final = sim.states(scene)
+
+	w := stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if *format == "csv" {
+		return writeCSV(w, sim.result)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sim.result)
+}
+
+func (s *simulation) states(scene *rube.Scene) []BodyState {
+	states := make([]BodyState, 0, len(scene.Bodies))
+	for _, e := range scene.Bodies {
+		b := e.Body
+		p := b.GetPosition()
+		v := b.GetLinearVelocity()
+		start := s.starts[b]
+		states = append(states, BodyState{
+			Step:            s.step,
+			Body:            s.names[b],
+			X:               p.X,
+			Y:               p.Y,
+			Angle:           b.GetAngle(),
+			VX:              v.X,
+			VY:              v.Y,
+			AngularVelocity: b.GetAngularVelocity(),
+			Awake:           b.IsAwake(),
+			Moved:           math.Hypot(p.X-start.X, p.Y-start.Y),
+		})
+	}
+	return states
+}
+
+func (s *simulation) contact(event string, contact box2d.B2ContactInterface) {
+	s.result.Contacts = append(s.result.Contacts, ContactEvent{
+		Step:  s.step,
+		Event: event,
+		BodyA: s.names[contact.GetFixtureA().GetBody()],
+		BodyB: s.names[contact.GetFixtureB().GetBody()],
+	})
+}
+
+// BeginContact implements the B2ContactListener interface.
+func (s *simulation) BeginContact(contact box2d.B2ContactInterface) { s.contact("begin", contact) }
+
+// EndContact implements the B2ContactListener interface.
+func (s *simulation) EndContact(contact box2d.B2ContactInterface) { s.contact("end", contact) }
+
+// PreSolve implements the B2ContactListener interface.
+func (s *simulation) PreSolve(contact box2d.B2ContactInterface, oldManifold box2d.B2Manifold) {}
+
+// PostSolve implements the B2ContactListener interface.
+func (s *simulation) PostSolve(contact box2d.B2ContactInterface, impulse *box2d.B2ContactImpulse) {}
+
+// writeCSV writes the result as a single table. The kind column says whether a
+// row is a trajectory sample, a contact event, or the final state of a body.
+func writeCSV(w io.Writer, r Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "step", "body", "other", "x", "y", "angle", "vx", "vy", "angularVelocity", "awake", "moved"})
+	float := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+	state := func(kind string, s BodyState) []string {
+		return []string{kind, strconv.Itoa(s.Step), s.Body, "",
+			float(s.X), float(s.Y), float(s.Angle), float(s.VX), float(s.VY), float(s.AngularVelocity),
+			strconv.FormatBool(s.Awake), float(s.Moved)}
+	}
+	for _, s := range r.Trajectories {
+		cw.Write(state("position", s))
+	}
+	for _, c := range r.Contacts {
+		cw.Write([]string{c.Event, strconv.Itoa(c.Step), c.BodyA, c.BodyB, "", "", "", "", "", "", "", ""})
+	}
+	for _, s := range r.Final {
+		cw.Write(state("final", s))
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a box dropped onto the ground, and a tower too thin to stay up
+const testScene = `{
+	"gravity": {"x": 0, "y": -10},
+	"positionIterations": 3,
+	"velocityIterations": 8,
+	"stepsPerSecond": 60,
+	"body": [
+		{"name": "ground", "type": 0, "position": 0, "fixture": [
+			{"friction": 0.6, "polygon": {"vertices": {"x": [-20, 20, 20, -20], "y": [-1, -1, 0, 0]}}}
+		]},
+		{"name": "box", "type": 2, "position": {"x": 0, "y": 2}, "awake": true, "fixture": [
+			{"density": 1, "friction": 0.6, "polygon": {"vertices": {"x": [-0.5, 0.5, 0.5, -0.5], "y": [-0.5, -0.5, 0.5, 0.5]}}}
+		]},
+		{"name": "tower", "type": 2, "position": {"x": 5, "y": 3}, "angle": 0.2, "awake": true, "fixture": [
+			{"density": 1, "friction": 0.6, "polygon": {"vertices": {"x": [-0.1, 0.1, 0.1, -0.1], "y": [-3, -3, 3, 3]}}}
+		]}
+	]
+}`
+
+func writeTestScene(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "scene.json")
+	if err := os.WriteFile(path, []byte(testScene), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-steps", "180", "-every", "60", writeTestScene(t)}, &out); err != nil {
+		t.Fatalf("run returned an error: %v", err)
+	}
+	var r Result
+	if err := json.Unmarshal(out.Bytes(), &r); err != nil {
+		t.Fatalf("output is not json: %v", err)
+	}
+
+	if r.Steps != 180 || r.Dt != 1.0/60.0 {
+		t.Errorf("wrong settings, got: %d steps of %v", r.Steps, r.Dt)
+	}
+	if len(r.Trajectories) != 9 {
+		t.Errorf("wrong number of trajectory samples, want: %d, got: %d", 9, len(r.Trajectories))
+	}
+	if len(r.Final) != 3 {
+		t.Fatalf("wrong number of final states, want: %d, got: %d", 3, len(r.Final))
+	}
+	box, tower := r.Final[1], r.Final[2]
+	if box.Body != "box" || box.Y > 0.6 || box.Y < 0.4 {
+		t.Errorf("box should have landed on the ground, got: %+v", box)
+	}
+	if tower.Moved < 1 {
+		t.Errorf("tower should have fallen over, got: %+v", tower)
+	}
+
+	var landed bool
+	for _, c := range r.Contacts {
+		if c.Event == "begin" && (c.BodyA == "ground" && c.BodyB == "box" || c.BodyA == "box" && c.BodyB == "ground") {
+			landed = true
+		}
+	}
+	if !landed {
+		t.Errorf("box landing was not recorded, got: %+v", r.Contacts)
+	}
+}
+
+func TestRunCSV(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.csv")
+	if err := run([]string{"-steps", "60", "-every", "0", "-format", "csv", "-o", out, writeTestScene(t)}, nil); err != nil {
+		t.Fatalf("run returned an error: %v", err)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not csv: %v", err)
+	}
+
+	kinds := make(map[string]int)
+	for _, row := range rows[1:] {
+		kinds[row[0]]++
+	}
+	if kinds["position"] != 0 || kinds["final"] != 3 || kinds["begin"] == 0 {
+		t.Errorf("wrong rows, got: %v", kinds)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"-format", "xml", "scene.json"},
+		{filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		if err := run(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("run %v should have returned an error", args)
+		}
+	}
+}
@@ -25,7 +25,10 @@ type CollisionEndMessage struct {
 // Type implements the engo.Message interface
 func (CollisionEndMessage) Type() string { return "CollisionEndMessage" }
 
-// PreSolveMessage is sent out before a step of the physics engine
+// PreSolveMessage is sent out before a step of the physics engine. Unlike the
+// start and end messages, it's sent while a PhysicsSystem re-simulates frames
+// after a Rewind too, since listeners can change the contact, such as by
+// disabling it.
 type PreSolveMessage struct {
 	Contact     box2d.B2ContactInterface
 	OldManifold box2d.B2Manifold
@@ -34,7 +37,8 @@ type PreSolveMessage struct {
 // Type implements the engo.Message interface
 func (PreSolveMessage) Type() string { return "PreSolveMessage" }
 
-// PostSolveMessage is sent out after a step of the physics engine
+// PostSolveMessage is sent out after a step of the physics engine. Like the
+// PreSolveMessage, it's sent while re-simulating frames after a Rewind too.
 type PostSolveMessage struct {
 	Contact box2d.B2ContactInterface
 	Impulse *box2d.B2ContactImpulse
@@ -97,6 +101,9 @@ func (c *CollisionSystem) Update(dt float32) {}
 // when a BeginContact callback is made by box2d, it sends a message containing
 // the information from the callback.
 func (c *CollisionSystem) BeginContact(contact box2d.B2ContactInterface) {
+	if replaying {
+		return
+	}
 	engo.Mailbox.Dispatch(CollisionStartMessage{
 		Contact: contact,
 	})
@@ -106,6 +113,9 @@ func (c *CollisionSystem) BeginContact(contact box2d.B2ContactInterface) {
 // when a EndContact callback is made by box2d, it sends a message containing
 // the information from the callback.
 func (c *CollisionSystem) EndContact(contact box2d.B2ContactInterface) {
+	if replaying {
+		return
+	}
 	engo.Mailbox.Dispatch(CollisionEndMessage{
 		Contact: contact,
 	})
@@ -0,0 +1,94 @@
+package engoBox2dSystem
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+// setCursor changes the OS cursor. It's a variable so it can be swapped out
+// where there's no window, such as in the tests.
+var setCursor = engo.SetCursor
+
+// CursorComponent is the cursor shown while the pointer is over the entity.
+// engo.CursorNone is the default cursor.
+type CursorComponent struct {
+	// HoverCursor is shown while the entity is hovered.
+	HoverCursor engo.Cursor
+	// DragCursor is shown while the entity is dragged. If it's CursorNone the
+	// HoverCursor is shown instead.
+	DragCursor engo.Cursor
+	// DisabledCursor is shown instead while the entity's MouseComponent is
+	// Disabled.
+	DisabledCursor engo.Cursor
+
+	// Tooltip is text to show while the entity is hovered. The MouseSystem
+	// doesn't draw it, get it from MouseSystem.Tooltip.
+	Tooltip string
+
+	// Priority picks whose cursor is shown when hovered entities overlap. The
+	// highest wins, and ties go to the entity added last. It's separate from
+	// the RenderComponent's z index, which can change while it's drawn.
+	Priority int
+}
+
+// current is the cursor to show for the entity.
+func (c *CursorComponent) current(e mouseEntity) engo.Cursor {
+	switch {
+	case e.MouseComponent.Disabled:
+		return c.DisabledCursor
+	case e.startedDragging && c.DragCursor != engo.CursorNone:
+		return c.DragCursor
+	}
+	return c.HoverCursor
+}
+
+// AddCursor gives an entity of the MouseSystem a cursor. The entity has to be
+// added to the MouseSystem first. The cursor is kept with the entity's
+// MouseComponent, so it goes away when the entity is removed. It isn't taken
+// by Add so that Add and the Pickable interface stay the same for entities
+// without a cursor.
+func (m *MouseSystem) AddCursor(basic *ecs.BasicEntity, cursor *CursorComponent) {
+	for _, e := range m.entities {
+		if e.ID() == basic.ID() {
+			e.MouseComponent.cursor = cursor
+			return
+		}
+	}
+}
+
+// Tooltip is the tooltip of the entity the cursor is over, and where the
+// pointer is in the world. It's empty if there's no tooltip.
+func (m *MouseSystem) Tooltip() (string, engo.Point) {
+	if m.hovered == nil {
+		return "", engo.Point{}
+	}
+	return m.hovered.Tooltip, engo.Point{X: m.mouseX, Y: m.mouseY}
+}
+
+// updateCursor picks which of the hovered entities with a cursor shows theirs
+// and sets it if it changed. An entity being dragged wins, then the one with
+// the highest Priority, then the one added last.
+func (m *MouseSystem) updateCursor(hovered []mouseEntity) {
+	var top *mouseEntity
+	for i := range hovered {
+		e := &hovered[i]
+		switch {
+		case top == nil, e.startedDragging && !top.startedDragging:
+			top = e
+		case top.startedDragging && !e.startedDragging:
+		case e.MouseComponent.cursor.Priority >= top.MouseComponent.cursor.Priority:
+			top = e
+		}
+	}
+
+	cursor := engo.CursorNone
+	m.hovered = nil
+	if top != nil {
+		m.hovered = top.MouseComponent.cursor
+		cursor = m.hovered.current(*top)
+	}
+	if cursor != m.cursor {
+		setCursor(cursor)
+		m.cursor = cursor
+	}
+}
@@ -0,0 +1,89 @@
+package engoBox2dSystem
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+)
+
+func TestMouseSystemCursor(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{2})
+
+	var set []engo.Cursor
+	setCursor = func(c engo.Cursor) { set = append(set, c) }
+	defer func() { setCursor = engo.SetCursor }()
+
+	sys.AddCursor(&basics[0], &CursorComponent{
+		HoverCursor: engo.CursorHand,
+		DragCursor:  engo.CursorHResize,
+		Tooltip:     "box",
+	})
+	sys.AddCursor(&basics[1], &CursorComponent{
+		HoverCursor:    engo.CursorHand,
+		DisabledCursor: engo.CursorCrosshair,
+	})
+	sys.entities[1].MouseComponent.Disabled = true
+
+	script := &ScriptedPointer{}
+	sys.Source = script
+	step := func(p Pointer, want engo.Cursor) {
+		t.Helper()
+		script.Push(p)
+		sys.Update(updateTime)
+		if sys.cursor != want {
+			t.Errorf("Wrong cursor for %+v, want: %v, got: %v", p, want, sys.cursor)
+		}
+	}
+
+	step(Pointer{X: 90, Y: 90, Action: engo.Move}, engo.CursorNone)
+	step(Pointer{X: 5, Y: 5, Action: engo.Move}, engo.CursorHand)
+	if tip, at := sys.Tooltip(); tip != "box" || at.X != 5 || at.Y != 5 {
+		t.Errorf("Tooltip should be for the hovered entity, got: %q at %v", tip, at)
+	}
+	step(Pointer{X: 5, Y: 5, Action: engo.Move}, engo.CursorHand)
+	step(Pointer{X: 5, Y: 5, Action: engo.Press, Button: engo.MouseButtonLeft}, engo.CursorHResize)
+	// dragging over another entity keeps the drag cursor
+	step(Pointer{X: 25, Y: 5, Action: engo.Move, Button: engo.MouseButtonLeft}, engo.CursorHResize)
+	step(Pointer{X: 90, Y: 90, Action: engo.Release, Button: engo.MouseButtonLeft}, engo.CursorNone)
+	if tip, _ := sys.Tooltip(); tip != "" {
+		t.Errorf("There should be no tooltip with nothing hovered, got: %q", tip)
+	}
+	// a disabled entity isn't hovered, but shows its disabled cursor
+	step(Pointer{X: 25, Y: 5, Action: engo.Move}, engo.CursorCrosshair)
+	if sys.entities[1].Hovered {
+		t.Errorf("Disabled entity should not be hovered")
+	}
+
+	want := []engo.Cursor{engo.CursorHand, engo.CursorHResize, engo.CursorNone, engo.CursorCrosshair}
+	if len(set) != len(want) {
+		t.Fatalf("Cursor should only be set when it changes, got: %v", set)
+	}
+	for i := range want {
+		if set[i] != want[i] {
+			t.Errorf("Cursor set out of order, want: %v, got: %v", want, set)
+			break
+		}
+	}
+
+	// with both hovered, the one added last wins ties, and the higher
+	// priority wins otherwise, no matter how they're drawn
+	sys.entities[1].SpaceComponent.Position.X = 0
+	sys.entities[0].RenderComponent = &common.RenderComponent{}
+	sys.entities[0].RenderComponent.SetZIndex(10)
+	step(Pointer{X: 5, Y: 5, Action: engo.Move}, engo.CursorCrosshair)
+	sys.entities[0].MouseComponent.cursor.Priority = 1
+	step(Pointer{X: 5, Y: 5, Action: engo.Move}, engo.CursorHand)
+	sys.entities[1].MouseComponent.cursor.Priority = 2
+	step(Pointer{X: 5, Y: 5, Action: engo.Move}, engo.CursorCrosshair)
+
+	// removing the hovered entity shows the other one's cursor
+	sys.Remove(basics[1])
+	step(Pointer{X: 5, Y: 5, Action: engo.Move}, engo.CursorHand)
+}
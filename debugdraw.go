@@ -0,0 +1,426 @@
+package engoBox2dSystem
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+)
+
+// DebugDrawSystemPriority makes sure the debug geometry is drawn after the
+// physics system has stepped, but before rendering.
+const DebugDrawSystemPriority = -100
+
+// DebugDrawLayer is a part of the World that can be debug drawn.
+type DebugDrawLayer uint
+
+const (
+	// DebugDrawShapes draws the outline of every fixture
+	DebugDrawShapes DebugDrawLayer = 1 << iota
+	// DebugDrawAABBs draws the broad-phase bounding box of every fixture
+	DebugDrawAABBs
+	// DebugDrawCenterOfMass draws the axes of each body at its center of mass
+	DebugDrawCenterOfMass
+	// DebugDrawJoints draws the lines between the bodies and anchors of joints
+	DebugDrawJoints
+	// DebugDrawContacts draws the contact points and normals of touching fixtures
+	DebugDrawContacts
+
+	// DebugDrawAll draws every layer
+	DebugDrawAll = DebugDrawShapes | DebugDrawAABBs | DebugDrawCenterOfMass | DebugDrawJoints | DebugDrawContacts
+)
+
+// Colors used for debug drawing, the same as the box2d testbed.
+var (
+	DebugColorInactive  = color.NRGBA{128, 128, 77, 255}
+	DebugColorStatic    = color.NRGBA{128, 230, 128, 255}
+	DebugColorKinematic = color.NRGBA{128, 128, 230, 255}
+	DebugColorAsleep    = color.NRGBA{153, 153, 153, 255}
+	DebugColorDynamic   = color.NRGBA{230, 179, 179, 255}
+	DebugColorJoint     = color.NRGBA{128, 204, 204, 255}
+	DebugColorAABB      = color.NRGBA{230, 77, 230, 255}
+	DebugColorContact   = color.NRGBA{77, 242, 77, 255}
+	DebugColorNormal    = color.NRGBA{230, 230, 77, 255}
+	DebugColorAxisX     = color.NRGBA{255, 0, 0, 255}
+	DebugColorAxisY     = color.NRGBA{0, 255, 0, 255}
+)
+
+// debugAxisScale is the length of the center of mass axes and contact normals,
+// in meters.
+const debugAxisScale = 0.4
+
+// DebugDraw receives the geometry of the World from DrawDebugData. Positions
+// and sizes are in box2d's meters. It's the same as box2d's b2Draw, which the
+// Go port doesn't have.
+type DebugDraw interface {
+	DrawPolygon(vertices []box2d.B2Vec2, c color.Color)
+	DrawSolidPolygon(vertices []box2d.B2Vec2, c color.Color)
+	DrawCircle(center box2d.B2Vec2, radius float64, c color.Color)
+	DrawSolidCircle(center box2d.B2Vec2, radius float64, axis box2d.B2Vec2, c color.Color)
+	DrawSegment(p1, p2 box2d.B2Vec2, c color.Color)
+	DrawTransform(xf box2d.B2Transform)
+	DrawPoint(p box2d.B2Vec2, size float64, c color.Color)
+}
+
+// DrawDebugData draws the given layers of the World to d.
+func DrawDebugData(d DebugDraw, layers DebugDrawLayer) {
+	if layers&DebugDrawShapes != 0 {
+		for b := World.GetBodyList(); b != nil; b = b.GetNext() {
+			xf := b.GetTransform()
+			for f := b.GetFixtureList(); f != nil; f = f.GetNext() {
+				var c color.Color
+				switch {
+				case !b.IsActive():
+					c = DebugColorInactive
+				case b.GetType() == box2d.B2BodyType.B2_staticBody:
+					c = DebugColorStatic
+				case b.GetType() == box2d.B2BodyType.B2_kinematicBody:
+					c = DebugColorKinematic
+				case !b.IsAwake():
+					c = DebugColorAsleep
+				default:
+					c = DebugColorDynamic
+				}
+				drawShape(d, f.GetShape(), xf, c)
+			}
+		}
+	}
+
+	if layers&DebugDrawJoints != 0 {
+		for j := World.GetJointList(); j != nil; j = j.GetNext() {
+			drawJoint(d, j)
+		}
+	}
+
+	if layers&DebugDrawAABBs != 0 {
+		bp := &World.M_contactManager.M_broadPhase
+		for b := World.GetBodyList(); b != nil; b = b.GetNext() {
+			if !b.IsActive() {
+				continue
+			}
+			for f := b.GetFixtureList(); f != nil; f = f.GetNext() {
+				for i := 0; i < f.M_proxyCount; i++ {
+					aabb := bp.GetFatAABB(f.M_proxies[i].ProxyId)
+					d.DrawPolygon([]box2d.B2Vec2{
+						aabb.LowerBound,
+						{X: aabb.UpperBound.X, Y: aabb.LowerBound.Y},
+						aabb.UpperBound,
+						{X: aabb.LowerBound.X, Y: aabb.UpperBound.Y},
+					}, DebugColorAABB)
+				}
+			}
+		}
+	}
+
+	if layers&DebugDrawCenterOfMass != 0 {
+		for b := World.GetBodyList(); b != nil; b = b.GetNext() {
+			xf := b.GetTransform()
+			xf.P = b.GetWorldCenter()
+			d.DrawTransform(xf)
+		}
+	}
+
+	if layers&DebugDrawContacts != 0 {
+		for c := World.GetContactList(); c != nil; c = c.GetNext() {
+			if !c.IsTouching() {
+				continue
+			}
+			var wm box2d.B2WorldManifold
+			c.GetWorldManifold(&wm)
+			for i := 0; i < c.GetManifold().PointCount; i++ {
+				p := wm.Points[i]
+				d.DrawPoint(p, 0.1, DebugColorContact)
+				d.DrawSegment(p, box2d.B2Vec2Add(p, box2d.B2Vec2MulScalar(debugAxisScale, wm.Normal)), DebugColorNormal)
+			}
+		}
+	}
+}
+
+func drawShape(d DebugDraw, shape box2d.B2ShapeInterface, xf box2d.B2Transform, c color.Color) {
+	switch s := shape.(type) {
+	case *box2d.B2CircleShape:
+		center := box2d.B2TransformVec2Mul(xf, s.M_p)
+		axis := box2d.B2RotVec2Mul(xf.Q, box2d.B2Vec2{X: 1, Y: 0})
+		d.DrawSolidCircle(center, s.M_radius, axis, c)
+	case *box2d.B2EdgeShape:
+		d.DrawSegment(box2d.B2TransformVec2Mul(xf, s.M_vertex1), box2d.B2TransformVec2Mul(xf, s.M_vertex2), c)
+	case *box2d.B2ChainShape:
+		v1 := box2d.B2TransformVec2Mul(xf, s.M_vertices[0])
+		for i := 1; i < s.M_count; i++ {
+			v2 := box2d.B2TransformVec2Mul(xf, s.M_vertices[i])
+			d.DrawSegment(v1, v2, c)
+			v1 = v2
+		}
+	case *box2d.B2PolygonShape:
+		vertices := make([]box2d.B2Vec2, s.M_count)
+		for i := range vertices {
+			vertices[i] = box2d.B2TransformVec2Mul(xf, s.M_vertices[i])
+		}
+		d.DrawSolidPolygon(vertices, c)
+	}
+}
+
+// anchoredJoint is a joint with anchors. Every joint type has them, but
+// box2d.B2JointInterface doesn't include them.
+type anchoredJoint interface {
+	GetAnchorA() box2d.B2Vec2
+	GetAnchorB() box2d.B2Vec2
+}
+
+func drawJoint(d DebugDraw, j box2d.B2JointInterface) {
+	anchored, ok := j.(anchoredJoint)
+	if !ok {
+		return
+	}
+	x1 := j.GetBodyA().GetTransform().P
+	x2 := j.GetBodyB().GetTransform().P
+	p1 := anchored.GetAnchorA()
+	p2 := anchored.GetAnchorB()
+
+	switch joint := j.(type) {
+	case *box2d.B2DistanceJoint:
+		d.DrawSegment(p1, p2, DebugColorJoint)
+	case *box2d.B2PulleyJoint:
+		s1 := joint.GetGroundAnchorA()
+		s2 := joint.GetGroundAnchorB()
+		d.DrawSegment(s1, p1, DebugColorJoint)
+		d.DrawSegment(s2, p2, DebugColorJoint)
+		d.DrawSegment(s1, s2, DebugColorJoint)
+	case *box2d.B2MouseJoint:
+		// the mouse joint is drawn by whatever is dragging it
+	default:
+		d.DrawSegment(x1, p1, DebugColorJoint)
+		d.DrawSegment(p1, p2, DebugColorJoint)
+		d.DrawSegment(x2, p2, DebugColorJoint)
+	}
+}
+
+// DebugLine is a line drawn by the DebugDrawSystem, in pixels.
+type DebugLine struct {
+	A, B  engo.Point
+	Color color.NRGBA
+}
+
+type debugDrawEntity struct {
+	ecs.BasicEntity
+	common.RenderComponent
+	common.SpaceComponent
+}
+
+// DebugDrawSystem draws the outlines of the World's fixtures, their AABBs,
+// centers of mass, joints, and contacts on top of the game. Each color of line
+// is a single entity added to the RenderSystem, so add the RenderSystem to the
+// world before this one.
+type DebugDrawSystem struct {
+	// Layers are the parts of the World that get drawn. It can be changed at
+	// any time, or use Toggle.
+	Layers DebugDrawLayer
+	// LineWidth is the width of the lines in pixels. Defaults to 1.
+	LineWidth float32
+	// CircleSegments is how many lines a circle is drawn with. Defaults to 16.
+	CircleSegments int
+	// ZIndex is where the debug lines are drawn. Defaults to 1000, which is
+	// on top of most things.
+	ZIndex float32
+
+	lines    []DebugLine
+	render   *common.RenderSystem
+	entities map[color.NRGBA]*debugDrawEntity
+}
+
+// New finds the RenderSystem to draw to.
+func (d *DebugDrawSystem) New(w *ecs.World) {
+	for _, system := range w.Systems() {
+		switch sys := system.(type) {
+		case *common.RenderSystem:
+			d.render = sys
+		}
+	}
+}
+
+// Priority implements the ecs.Prioritizer interface.
+func (d *DebugDrawSystem) Priority() int { return DebugDrawSystemPriority }
+
+// Remove doesn't do anything, since the system makes its own entities.
+func (d *DebugDrawSystem) Remove(basic ecs.BasicEntity) {}
+
+// Toggle turns the given layers on if they're off, or off if they're on.
+func (d *DebugDrawSystem) Toggle(layers DebugDrawLayer) {
+	d.Layers ^= layers
+}
+
+// Lines are the lines drawn during the last Update.
+func (d *DebugDrawSystem) Lines() []DebugLine {
+	return d.lines
+}
+
+// Update draws the World and updates the debug entities.
+func (d *DebugDrawSystem) Update(dt float32) {
+	d.lines = d.lines[:0]
+	DrawDebugData(d, d.Layers)
+
+	byColor := make(map[color.NRGBA][]DebugLine)
+	for _, l := range d.lines {
+		byColor[l.Color] = append(byColor[l.Color], l)
+	}
+	if d.entities == nil {
+		d.entities = make(map[color.NRGBA]*debugDrawEntity)
+	}
+	for c, e := range d.entities {
+		if _, ok := byColor[c]; !ok {
+			e.Hidden = true
+		}
+	}
+	zIndex := d.ZIndex
+	if zIndex == 0 {
+		zIndex = 1000
+	}
+	for c, lines := range byColor {
+		space, shape := debugTriangles(lines, d.lineWidth())
+		e, ok := d.entities[c]
+		if !ok {
+			e = &debugDrawEntity{BasicEntity: ecs.NewBasic()}
+			e.RenderComponent = common.RenderComponent{Drawable: shape, Color: c}
+			e.SetZIndex(zIndex)
+			e.SpaceComponent = space
+			d.entities[c] = e
+			if d.render != nil {
+				d.render.Add(&e.BasicEntity, &e.RenderComponent, &e.SpaceComponent)
+			}
+			continue
+		}
+		// the RenderSystem only sizes the buffer when it's empty, so it has to
+		// be thrown away when the number of points changes
+		if old, ok := e.Drawable.(common.ComplexTriangles); !ok || len(old.Points) != len(shape.Points) {
+			e.BufferContent = nil
+		}
+		e.Drawable = shape
+		e.SpaceComponent = space
+		e.Hidden = false
+	}
+}
+
+func (d *DebugDrawSystem) lineWidth() float32 {
+	if d.LineWidth <= 0 {
+		return 1
+	}
+	return d.LineWidth
+}
+
+func (d *DebugDrawSystem) circleSegments() int {
+	if d.CircleSegments < 3 {
+		return 16
+	}
+	return d.CircleSegments
+}
+
+func (d *DebugDrawSystem) line(p1, p2 box2d.B2Vec2, c color.Color) {
+	d.lines = append(d.lines, DebugLine{
+		A:     Conv.ToEngoPoint(p1),
+		B:     Conv.ToEngoPoint(p2),
+		Color: color.NRGBAModel.Convert(c).(color.NRGBA),
+	})
+}
+
+// DrawPolygon implements the DebugDraw interface
+func (d *DebugDrawSystem) DrawPolygon(vertices []box2d.B2Vec2, c color.Color) {
+	for i := range vertices {
+		d.line(vertices[i], vertices[(i+1)%len(vertices)], c)
+	}
+}
+
+// DrawSolidPolygon implements the DebugDraw interface. It's drawn as an outline.
+func (d *DebugDrawSystem) DrawSolidPolygon(vertices []box2d.B2Vec2, c color.Color) {
+	d.DrawPolygon(vertices, c)
+}
+
+// DrawCircle implements the DebugDraw interface
+func (d *DebugDrawSystem) DrawCircle(center box2d.B2Vec2, radius float64, c color.Color) {
+	n := d.circleSegments()
+	vertices := make([]box2d.B2Vec2, n)
+	for i := range vertices {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		vertices[i] = box2d.B2Vec2{X: center.X + radius*math.Cos(angle), Y: center.Y + radius*math.Sin(angle)}
+	}
+	d.DrawPolygon(vertices, c)
+}
+
+// DrawSolidCircle implements the DebugDraw interface. It's drawn as an outline
+// with a line from the center along the axis.
+func (d *DebugDrawSystem) DrawSolidCircle(center box2d.B2Vec2, radius float64, axis box2d.B2Vec2, c color.Color) {
+	d.DrawCircle(center, radius, c)
+	d.line(center, box2d.B2Vec2Add(center, box2d.B2Vec2MulScalar(radius, axis)), c)
+}
+
+// DrawSegment implements the DebugDraw interface
+func (d *DebugDrawSystem) DrawSegment(p1, p2 box2d.B2Vec2, c color.Color) {
+	d.line(p1, p2, c)
+}
+
+// DrawTransform implements the DebugDraw interface
+func (d *DebugDrawSystem) DrawTransform(xf box2d.B2Transform) {
+	d.line(xf.P, box2d.B2Vec2Add(xf.P, box2d.B2Vec2MulScalar(debugAxisScale, xf.Q.GetXAxis())), DebugColorAxisX)
+	d.line(xf.P, box2d.B2Vec2Add(xf.P, box2d.B2Vec2MulScalar(debugAxisScale, xf.Q.GetYAxis())), DebugColorAxisY)
+}
+
+// DrawPoint implements the DebugDraw interface. It's drawn as a square size
+// meters wide.
+func (d *DebugDrawSystem) DrawPoint(p box2d.B2Vec2, size float64, c color.Color) {
+	h := size / 2
+	d.DrawPolygon([]box2d.B2Vec2{
+		{X: p.X - h, Y: p.Y - h},
+		{X: p.X + h, Y: p.Y - h},
+		{X: p.X + h, Y: p.Y + h},
+		{X: p.X - h, Y: p.Y + h},
+	}, c)
+}
+
+// debugTriangles turns the lines into quads two triangles each, and returns
+// them as a shape along with the space it's drawn in. The points of the shape
+// are from 0 to 1 across the space, which is how ComplexTriangles are drawn.
+func debugTriangles(lines []DebugLine, width float32) (common.SpaceComponent, common.ComplexTriangles) {
+	half := width / 2
+	min := engo.Point{X: float32(math.Inf(1)), Y: float32(math.Inf(1))}
+	max := engo.Point{X: float32(math.Inf(-1)), Y: float32(math.Inf(-1))}
+	for _, l := range lines {
+		for _, p := range []engo.Point{l.A, l.B} {
+			if p.X < min.X {
+				min.X = p.X
+			}
+			if p.Y < min.Y {
+				min.Y = p.Y
+			}
+			if p.X > max.X {
+				max.X = p.X
+			}
+			if p.Y > max.Y {
+				max.Y = p.Y
+			}
+		}
+	}
+	min.X, min.Y = min.X-half, min.Y-half
+	max.X, max.Y = max.X+half, max.Y+half
+	space := common.SpaceComponent{Position: min, Width: max.X - min.X, Height: max.Y - min.Y}
+
+	local := func(x, y float32) engo.Point {
+		return engo.Point{X: (x - min.X) / space.Width, Y: (y - min.Y) / space.Height}
+	}
+	points := make([]engo.Point, 0, len(lines)*6)
+	for _, l := range lines {
+		dx, dy := l.B.X-l.A.X, l.B.Y-l.A.Y
+		length := float32(math.Hypot(float64(dx), float64(dy)))
+		if length == 0 {
+			continue
+		}
+		// offset perpendicular to the line by half the width
+		nx, ny := -dy/length*half, dx/length*half
+		a1, a2 := local(l.A.X+nx, l.A.Y+ny), local(l.A.X-nx, l.A.Y-ny)
+		b1, b2 := local(l.B.X+nx, l.B.Y+ny), local(l.B.X-nx, l.B.Y-ny)
+		points = append(points, a1, b1, b2, a1, b2, a2)
+	}
+	return space, common.ComplexTriangles{Points: points}
+}
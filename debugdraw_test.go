@@ -0,0 +1,173 @@
+package engoBox2dSystem
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+)
+
+func debugLinesWithColor(lines []DebugLine, c color.NRGBA) []DebugLine {
+	var found []DebugLine
+	for _, l := range lines {
+		if l.Color == c {
+			found = append(found, l)
+		}
+	}
+	return found
+}
+
+func TestDebugDrawShapes(t *testing.T) {
+	newTestWorld(t, 0)
+
+	box := testBox(20, 20)
+	addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 100, Y: 100}, 0, box, box2d.B2FixtureDef{Density: 1})
+
+	circle := box2d.NewB2CircleShape()
+	circle.M_radius = Conv.PxToMeters(10)
+	addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 200, Y: 100}, 0, circle, box2d.B2FixtureDef{Density: 1})
+
+	d := &DebugDrawSystem{Layers: DebugDrawShapes}
+	d.Update(1.0 / 60.0)
+
+	boxLines := debugLinesWithColor(d.Lines(), DebugColorStatic)
+	want := []engo.Point{{X: 90, Y: 90}, {X: 110, Y: 90}, {X: 110, Y: 110}, {X: 90, Y: 110}}
+	if len(boxLines) != 4 {
+		t.Fatalf("wrong number of box lines, want: %d, got: %d", 4, len(boxLines))
+	}
+	for i, l := range boxLines {
+		if !l.A.Equal(want[i]) || !l.B.Equal(want[(i+1)%4]) {
+			t.Errorf("box line %d is wrong, want: %v to %v, got: %v to %v", i, want[i], want[(i+1)%4], l.A, l.B)
+		}
+	}
+
+	circleLines := debugLinesWithColor(d.Lines(), DebugColorDynamic)
+	if len(circleLines) != 17 {
+		t.Fatalf("wrong number of circle lines, want: %d, got: %d", 17, len(circleLines))
+	}
+	for _, l := range circleLines[:16] {
+		if r := l.A.PointDistance(engo.Point{X: 200, Y: 100}); !engo.FloatEqual(r, 10) {
+			t.Errorf("circle vertex is not on the circle, distance: %v", r)
+		}
+	}
+	if axis := circleLines[16]; !axis.A.Equal(engo.Point{X: 200, Y: 100}) || !axis.B.Equal(engo.Point{X: 210, Y: 100}) {
+		t.Errorf("circle axis is wrong, got: %v to %v", axis.A, axis.B)
+	}
+}
+
+func TestDebugDrawLayers(t *testing.T) {
+	newTestWorld(t, 0)
+
+	box := testBox(20, 20)
+	a := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 100, Y: 100}, 0, box, box2d.B2FixtureDef{Density: 1})
+	b := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 115, Y: 100}, 0, box, box2d.B2FixtureDef{Density: 1})
+	jointDef := box2d.MakeB2RevoluteJointDef()
+	jointDef.Initialize(a, b, Conv.ToBox2d2Vec(engo.Point{X: 110, Y: 120}))
+	World.CreateJoint(&jointDef)
+	// joined bodies don't collide, so the floor gives them contacts
+	addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 100, Y: 115}, 0, box, box2d.B2FixtureDef{Density: 1})
+	stepWorld(1.0 / 60.0)
+
+	d := &DebugDrawSystem{}
+	counts := func() map[color.NRGBA]int {
+		d.Update(1.0 / 60.0)
+		c := make(map[color.NRGBA]int)
+		for _, l := range d.Lines() {
+			c[l.Color]++
+		}
+		return c
+	}
+
+	if c := counts(); len(c) != 0 {
+		t.Errorf("nothing should be drawn without layers, got: %v", c)
+	}
+
+	d.Toggle(DebugDrawAll)
+	c := counts()
+	if c[DebugColorDynamic] != 8 {
+		t.Errorf("wrong number of shape lines, want: %d, got: %d", 8, c[DebugColorDynamic])
+	}
+	if c[DebugColorAABB] != 12 {
+		t.Errorf("wrong number of AABB lines, want: %d, got: %d", 12, c[DebugColorAABB])
+	}
+	if c[DebugColorJoint] != 3 {
+		t.Errorf("wrong number of joint lines, want: %d, got: %d", 3, c[DebugColorJoint])
+	}
+	if c[DebugColorAxisX] != 3 || c[DebugColorAxisY] != 3 {
+		t.Errorf("wrong number of center of mass lines, got: %d, %d", c[DebugColorAxisX], c[DebugColorAxisY])
+	}
+	if c[DebugColorContact] == 0 || c[DebugColorNormal] == 0 || c[DebugColorContact] != 4*c[DebugColorNormal] {
+		t.Errorf("contacts not drawn correctly, points: %d, normals: %d", c[DebugColorContact], c[DebugColorNormal])
+	}
+
+	// the center of mass axes start at the body's center
+	com := debugLinesWithColor(d.Lines(), DebugColorAxisX)
+	center := Conv.ToEngoPoint(a.GetWorldCenter())
+	if !com[0].A.Equal(center) && !com[1].A.Equal(center) && !com[2].A.Equal(center) {
+		t.Errorf("center of mass not drawn at the body's center %v, got: %v", center, com)
+	}
+
+	d.Toggle(DebugDrawShapes | DebugDrawContacts)
+	c = counts()
+	if c[DebugColorDynamic] != 0 || c[DebugColorContact] != 0 {
+		t.Errorf("toggled off layers were still drawn, got: %v", c)
+	}
+	if c[DebugColorAABB] != 12 {
+		t.Errorf("toggling other layers changed the AABBs, got: %d", c[DebugColorAABB])
+	}
+	if e, ok := d.entities[DebugColorDynamic]; !ok || !e.Hidden {
+		t.Errorf("entity for a color that isn't drawn should be hidden")
+	}
+	if e := d.entities[DebugColorAABB]; e.Hidden {
+		t.Errorf("entity for a color that is drawn should not be hidden")
+	}
+}
+
+func TestDebugDrawGrows(t *testing.T) {
+	newTestWorld(t, 0)
+	box := testBox(20, 20)
+	addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 100, Y: 100}, 0, box, box2d.B2FixtureDef{Density: 1})
+
+	d := &DebugDrawSystem{Layers: DebugDrawShapes}
+	d.Update(1.0 / 60.0)
+	e := d.entities[DebugColorStatic]
+	// as the RenderSystem's shader would
+	e.BufferContent = make([]float32, len(e.Drawable.(common.ComplexTriangles).Points)*6)
+	d.Update(1.0 / 60.0)
+	if e.BufferContent == nil {
+		t.Errorf("buffer should be kept while the geometry is the same size")
+	}
+
+	addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 200, Y: 100}, 0, box, box2d.B2FixtureDef{Density: 1})
+	d.Update(1.0 / 60.0)
+	if e.BufferContent != nil {
+		t.Errorf("buffer should be thrown away when the geometry grows")
+	}
+}
+
+func TestDebugTriangles(t *testing.T) {
+	lines := []DebugLine{
+		{A: engo.Point{X: 0, Y: 0}, B: engo.Point{X: 10, Y: 0}},
+		{A: engo.Point{X: 5, Y: 5}, B: engo.Point{X: 5, Y: 5}},
+	}
+	space, shape := debugTriangles(lines, 2)
+
+	if !space.Position.Equal(engo.Point{X: -1, Y: -1}) || space.Width != 12 || space.Height != 7 {
+		t.Errorf("wrong space, got: %v %v x %v", space.Position, space.Width, space.Height)
+	}
+	if len(shape.Points) != 6 {
+		t.Fatalf("zero length lines should be skipped, want %d points, got: %d", 6, len(shape.Points))
+	}
+	// back in pixels, the quad goes from (0, -1) to (10, 1)
+	want := []engo.Point{{X: 0, Y: 1}, {X: 10, Y: 1}, {X: 10, Y: -1}, {X: 0, Y: 1}, {X: 10, Y: -1}, {X: 0, Y: -1}}
+	for i, p := range shape.Points {
+		px := engo.Point{X: p.X*space.Width + space.Position.X, Y: p.Y*space.Height + space.Position.Y}
+		if math.Abs(float64(px.X-want[i].X)) > 1e-5 || math.Abs(float64(px.Y-want[i].Y)) > 1e-5 {
+			t.Errorf("vertex %d is wrong, want: %v, got: %v", i, want[i], px)
+		}
+	}
+}
@@ -23,6 +23,7 @@ type guy struct {
 	common.RenderComponent
 	common.SpaceComponent
 	engoBox2dSystem.Box2dComponent
+	engoBox2dSystem.CharacterControllerComponent
 }
 
 type wall struct {
@@ -49,6 +50,7 @@ func (*defaultScene) Setup(u engo.Updater) {
 
 	w.AddSystem(&common.RenderSystem{})
 	w.AddSystem(&controlSystem{})
+	w.AddSystem(&engoBox2dSystem.CharacterControllerSystem{})
 
 	//add box2d systems
 	w.AddSystem(&engoBox2dSystem.PhysicsSystem{VelocityIterations: 3, PositionIterations: 8})
@@ -106,6 +108,9 @@ func (*defaultScene) Setup(u engo.Updater) {
 	}
 	dude.Box2dComponent.Body.CreateFixtureFromDef(&dudeFixtureDef)
 
+	// jump high enough to reach the floors, but not hit the ceiling
+	dude.CharacterControllerComponent.JumpSpeed = 350
+
 	// Add it to appropriate systems
 	for _, system := range w.Systems() {
 		switch sys := system.(type) {
@@ -114,7 +119,9 @@ func (*defaultScene) Setup(u engo.Updater) {
 		case *engoBox2dSystem.PhysicsSystem:
 			sys.Add(&dude.BasicEntity, &dude.SpaceComponent, &dude.Box2dComponent)
 		case *controlSystem:
-			sys.Add(&dude.BasicEntity, &dude.Box2dComponent)
+			sys.Add(&dude.BasicEntity, &dude.CharacterControllerComponent)
+		case *engoBox2dSystem.CharacterControllerSystem:
+			sys.Add(&dude.BasicEntity, &dude.CharacterControllerComponent, &dude.Box2dComponent)
 		case *engoBox2dSystem.CollisionSystem:
 			sys.Add(&dude.BasicEntity, &dude.SpaceComponent, &dude.Box2dComponent)
 		case *starCollectionSystem:
@@ -435,11 +442,17 @@ type controlSystem struct {
 
 type controlEntity struct {
 	*ecs.BasicEntity
-	*engoBox2dSystem.Box2dComponent
+	*engoBox2dSystem.CharacterControllerComponent
 }
 
-func (c *controlSystem) Add(basic *ecs.BasicEntity, box *engoBox2dSystem.Box2dComponent) {
-	c.entities = append(c.entities, controlEntity{basic, box})
+// Priority runs the controls before the CharacterControllerSystem, so the
+// input is used the same frame.
+func (*controlSystem) Priority() int {
+	return engoBox2dSystem.CharacterControllerSystemPriority + 1
+}
+
+func (c *controlSystem) Add(basic *ecs.BasicEntity, character *engoBox2dSystem.CharacterControllerComponent) {
+	c.entities = append(c.entities, controlEntity{basic, character})
 }
 
 func (c *controlSystem) Remove(basic ecs.BasicEntity) {
@@ -457,13 +470,14 @@ func (c *controlSystem) Remove(basic ecs.BasicEntity) {
 
 func (c *controlSystem) Update(dt float32) {
 	for _, e := range c.entities {
-		if engo.Input.Button("up").JustPressed() {
-			e.Body.ApplyLinearImpulseToCenter(box2d.B2Vec2{X: 0, Y: -500}, true)
-		} else if engo.Input.Button("left").JustPressed() {
-			e.Body.SetLinearVelocity(box2d.B2Vec2{X: -10, Y: e.Body.GetLinearVelocity().Y})
-		} else if engo.Input.Button("right").JustPressed() {
-			e.Body.SetLinearVelocity(box2d.B2Vec2{X: 10, Y: e.Body.GetLinearVelocity().Y})
+		e.Move = 0
+		if engo.Input.Button("left").Down() {
+			e.Move--
+		}
+		if engo.Input.Button("right").Down() {
+			e.Move++
 		}
+		e.Jump = engo.Input.Button("up").Down()
 	}
 }
 
@@ -0,0 +1,169 @@
+package engoBox2dSystem
+
+import (
+	"math"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// EffectorKind is the kind of force an EffectorComponent applies.
+type EffectorKind uint8
+
+const (
+	// EffectorDirectional pushes bodies along Direction, like wind.
+	EffectorDirectional EffectorKind = iota
+	// EffectorPoint pulls bodies toward the center of the effector's body, or
+	// pushes them away if Strength is negative, like a planet.
+	EffectorPoint
+	// EffectorVortex swirls bodies around the center of the effector's body.
+	// Positive Strength is clockwise on screen.
+	EffectorVortex
+)
+
+// Falloff is how the strength of an effector or explosion shrinks with
+// distance from its center. It's never stronger than the full strength.
+type Falloff uint8
+
+const (
+	// FalloffNone is the full strength everywhere.
+	FalloffNone Falloff = iota
+	// FalloffLinear is the full strength at the center, down to nothing at the
+	// radius.
+	FalloffLinear
+	// FalloffInverseSquare is the full strength up to MinDistance, then quarters
+	// each time the distance doubles, like gravity.
+	FalloffInverseSquare
+)
+
+// scale is the fraction of the full strength left distance meters from the
+// center, with the radius in meters and minDistance in pixels.
+func (f Falloff) scale(distance, radius float64, minDistance float32) float64 {
+	switch f {
+	case FalloffLinear:
+		if radius <= 0 {
+			return 0
+		}
+		return math.Max(0, 1-distance/radius)
+	case FalloffInverseSquare:
+		min := Conv.PxToMeters(defaultFloat(minDistance, 20))
+		d := math.Max(distance, min)
+		return min * min / (d * d)
+	}
+	return 1
+}
+
+// EffectorComponent applies a force to the dynamic bodies overlapping the
+// sensor fixtures of its body each step.
+type EffectorComponent struct {
+	Kind EffectorKind
+	// Strength of the force, in newtons. If Acceleration is set, it's in m/s^2
+	// instead, so all bodies are moved the same no matter their mass.
+	Strength     float64
+	Acceleration bool
+	// Direction is the direction of a directional effector.
+	Direction engo.Point
+
+	Falloff Falloff
+	// Radius in pixels for FalloffLinear.
+	Radius float32
+	// MinDistance in pixels for FalloffInverseSquare. Defaults to 20.
+	MinDistance float32
+
+	// Mask is which collision categories are affected. A body is affected if
+	// any of its fixtures' CategoryBits are in the mask. Fixtures without
+	// CategoryBits are in category 1, box2d's default. Zero affects all.
+	Mask uint16
+}
+
+type effectorEntity struct {
+	*ecs.BasicEntity
+	*EffectorComponent
+	*Box2dComponent
+}
+
+var ignoringEffectors = make(map[*box2d.B2Body]bool)
+
+// IgnoreEffectors sets whether effectors are applied to the body.
+func (b *Box2dComponent) IgnoreEffectors(ignore bool) {
+	if ignore {
+		ignoringEffectors[b.Body] = true
+	} else {
+		delete(ignoringEffectors, b.Body)
+	}
+}
+
+// AddEffector adds an effector to the physics system. The fixtures of the
+// effector's body should be sensors.
+func (b *PhysicsSystem) AddEffector(basic *ecs.BasicEntity, effector *EffectorComponent, box *Box2dComponent) {
+	b.effectors = append(b.effectors, effectorEntity{basic, effector, box})
+}
+
+// applyEffectors applies the force of every effector to the bodies it overlaps.
+func (b *PhysicsSystem) applyEffectors() {
+	for _, ef := range b.effectors {
+		affected := make(map[*box2d.B2Body]bool)
+		for ce := ef.Body.GetContactList(); ce != nil; ce = ce.Next {
+			c := ce.Contact
+			if !c.IsTouching() {
+				continue
+			}
+			other := c.GetFixtureB()
+			if other.GetBody() == ef.Body {
+				other = c.GetFixtureA()
+			}
+			body := other.GetBody()
+			if affected[body] || ignoringEffectors[body] || !ef.affects(other) {
+				continue
+			}
+			affected[body] = true
+			body.ApplyForceToCenter(ef.force(body), true)
+		}
+	}
+}
+
+// affects checks if the effector pushes the fixture's body.
+func (ef effectorEntity) affects(f *box2d.B2Fixture) bool {
+	mask := ef.Mask
+	if mask == 0 {
+		mask = 0xFFFF
+	}
+	return !f.IsSensor() && f.GetBody().GetType() == box2d.B2BodyType.B2_dynamicBody &&
+		fixtureCategory(f)&mask != 0
+}
+
+// force is the force the effector puts on the body.
+func (ef effectorEntity) force(body *box2d.B2Body) box2d.B2Vec2 {
+	toCenter := box2d.B2Vec2Sub(ef.Body.GetWorldCenter(), body.GetWorldCenter())
+	distance := toCenter.Length()
+
+	var dir box2d.B2Vec2
+	switch ef.Kind {
+	case EffectorDirectional:
+		dir = Conv.ToBox2d2Vec(ef.Direction)
+	case EffectorPoint:
+		dir = toCenter
+	case EffectorVortex:
+		dir = box2d.B2Vec2{X: toCenter.Y, Y: -toCenter.X}
+	}
+	if dir.Normalize() == 0 {
+		return box2d.B2Vec2{}
+	}
+
+	strength := ef.Strength * ef.Falloff.scale(distance, Conv.PxToMeters(ef.Radius), ef.MinDistance)
+	if ef.Acceleration {
+		strength *= body.GetMass()
+	}
+	return box2d.B2Vec2MulScalar(strength, dir)
+}
+
+// fixtureCategory returns the fixture's collision category, treating none as
+// category 1, box2d's default.
+func fixtureCategory(f *box2d.B2Fixture) uint16 {
+	if category := f.GetFilterData().CategoryBits; category != 0 {
+		return category
+	}
+	return 0x0001
+}
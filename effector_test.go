@@ -0,0 +1,122 @@
+package engoBox2dSystem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+func TestEffectorDirectional(t *testing.T) {
+	newTestWorld(t, 0)
+	sys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+	effector := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 0, Y: 0}, 0, testBox(400, 400), box2d.B2FixtureDef{IsSensor: true})
+	basic := ecs.NewBasic()
+	sys.AddEffector(&basic, &EffectorComponent{
+		Kind:      EffectorDirectional,
+		Strength:  10,
+		Direction: engo.Point{X: 1, Y: 0},
+		Mask:      0x0001,
+	}, &Box2dComponent{Body: effector})
+
+	light := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: -100}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	heavy := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 2})
+	outside := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 300}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	masked := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 100}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1, Filter: box2d.B2Filter{CategoryBits: 0x0002}})
+	optOut := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 150}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	(&Box2dComponent{Body: optOut}).IgnoreEffectors(true)
+
+	// contacts with the effector are found in the first step
+	stepFrames(61, sys.Update)
+	// 10 N on 1 kg for a second
+	if v := light.GetLinearVelocity(); math.Abs(v.X-10) > 1e-6 || v.Y != 0 {
+		t.Errorf("wind should push the body, want: (10, 0), got: %v", v)
+	}
+	if v := heavy.GetLinearVelocity().X; math.Abs(v-5) > 1e-6 {
+		t.Errorf("wind should push the heavy body slower, want: 5, got: %v", v)
+	}
+	for name, b := range map[string]*box2d.B2Body{"outside": outside, "masked": masked, "opted out": optOut} {
+		if v := b.GetLinearVelocity(); v != (box2d.B2Vec2{}) {
+			t.Errorf("%v body should not be pushed, got: %v", name, v)
+		}
+	}
+
+	(&Box2dComponent{Body: optOut}).DestroyBody()
+	removeBodies()
+	if len(ignoringEffectors) != 0 {
+		t.Errorf("destroyed body should be forgotten")
+	}
+}
+
+func TestEffectorPoint(t *testing.T) {
+	newTestWorld(t, 0)
+	sys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+	// an attractor, a repulsor, and a vortex, 1000 pixels apart
+	for i, ef := range []*EffectorComponent{
+		{Kind: EffectorPoint, Strength: 10, Acceleration: true},
+		{Kind: EffectorPoint, Strength: -10},
+		{Kind: EffectorVortex, Strength: 10},
+	} {
+		body := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: float32(i * 1000), Y: 0}, 0, testBox(400, 400), box2d.B2FixtureDef{IsSensor: true})
+		basic := ecs.NewBasic()
+		sys.AddEffector(&basic, ef, &Box2dComponent{Body: body})
+	}
+
+	light := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	heavy := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 100}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 4})
+	repelled := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 1100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	swirled := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 2100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+
+	stepFrames(2, sys.Update)
+	if v := light.GetLinearVelocity(); v.X >= 0 || math.Abs(v.Y) > 1e-9 {
+		t.Errorf("attractor should pull the body left, got: %v", v)
+	}
+	if v := heavy.GetLinearVelocity(); v.Y >= 0 || math.Abs(v.Y-light.GetLinearVelocity().X) > 1e-9 {
+		t.Errorf("attractor should pull the heavy body up as fast, got: %v", v)
+	}
+	if v := repelled.GetLinearVelocity(); v.X <= 0 {
+		t.Errorf("repulsor should push the body right, got: %v", v)
+	}
+	if v := swirled.GetLinearVelocity(); v.Y <= 0 || math.Abs(v.X) > 1e-3 {
+		t.Errorf("vortex should push the body down, got: %v", v)
+	}
+}
+
+func TestEffectorFalloff(t *testing.T) {
+	newTestWorld(t, 0)
+	sys := &PhysicsSystem{}
+	ef := &EffectorComponent{Kind: EffectorPoint, Strength: 10, Radius: 100}
+	center := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 0, Y: 0}, 0, testBox(400, 400), box2d.B2FixtureDef{IsSensor: true})
+	basic := ecs.NewBasic()
+	sys.AddEffector(&basic, ef, &Box2dComponent{Body: center})
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 50, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	e := effectorEntity{EffectorComponent: ef, Box2dComponent: &Box2dComponent{Body: center}}
+
+	for _, test := range []struct {
+		falloff Falloff
+		min     float32
+		at      float32
+		want    float64
+	}{
+		{FalloffNone, 0, 50, 10},
+		{FalloffLinear, 0, 50, 5},
+		{FalloffLinear, 0, 150, 0},
+		{FalloffInverseSquare, 50, 50, 10},
+		{FalloffInverseSquare, 50, 100, 2.5},
+		// full strength inside MinDistance
+		{FalloffInverseSquare, 50, 10, 10},
+		// MinDistance defaults to 20 pixels
+		{FalloffInverseSquare, 0, 40, 2.5},
+		{FalloffInverseSquare, 0, 0.5, 10},
+	} {
+		ef.Falloff = test.falloff
+		ef.MinDistance = test.min
+		body.SetTransform(Conv.ToBox2d2Vec(engo.Point{X: test.at, Y: 0}), 0)
+		if got := -e.force(body).X; math.Abs(got-test.want) > 1e-6*test.want {
+			t.Errorf("falloff %v at %v pixels, want: %v, got: %v", test.falloff, test.at, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package engoBox2dSystem
+
+import (
+	"math"
+
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// ExplosionOptions changes how Explode pushes bodies.
+type ExplosionOptions struct {
+	// Falloff is how the impulse shrinks with distance from the center, the
+	// same as an EffectorComponent's with the explosion's radius.
+	Falloff Falloff
+	// MinDistance in pixels for FalloffInverseSquare. Defaults to 20.
+	MinDistance float32
+	// Occlusion keeps bodies behind static bodies, such as walls, from being
+	// pushed.
+	Occlusion bool
+	// Mask is which collision categories are pushed, like an EffectorComponent's.
+	// Zero pushes all.
+	Mask uint16
+}
+
+// ExplosionHit is a body pushed by Explode.
+type ExplosionHit struct {
+	Body *box2d.B2Body
+	// EntityID is the ID of the body's entity, if it was added to the
+	// CollisionSystem.
+	EntityID    uint64
+	HasEntityID bool
+	// Impulse is the total impulse the body received in kg m/s.
+	Impulse box2d.B2Vec2
+}
+
+// Explode pushes every dynamic body within radius pixels of the center away
+// from it. Each fixture gets the impulse, in kg m/s, at its nearest point to the
+// center, so bodies are spun as well as pushed. opts can be nil.
+//
+// The bodies pushed are returned along with the impulse each got, so they can
+// be damaged.
+func Explode(center engo.Point, radius float32, impulse float64, opts *ExplosionOptions) []ExplosionHit {
+	if opts == nil {
+		opts = &ExplosionOptions{}
+	}
+	mask := opts.Mask
+	if mask == 0 {
+		mask = 0xFFFF
+	}
+	c := Conv.ToBox2d2Vec(center)
+	r := Conv.PxToMeters(radius)
+
+	// a chain is found once for each of its edges
+	var fixtures []*box2d.B2Fixture
+	found := make(map[*box2d.B2Fixture]bool)
+	aabb := box2d.B2AABB{
+		LowerBound: box2d.B2Vec2{X: c.X - r, Y: c.Y - r},
+		UpperBound: box2d.B2Vec2{X: c.X + r, Y: c.Y + r},
+	}
+	World.QueryAABB(func(f *box2d.B2Fixture) bool {
+		if !found[f] && !f.IsSensor() && f.GetBody().GetType() == box2d.B2BodyType.B2_dynamicBody &&
+			fixtureCategory(f)&mask != 0 {
+			found[f] = true
+			fixtures = append(fixtures, f)
+		}
+		return true
+	}, aabb)
+
+	var hits []ExplosionHit
+	index := make(map[*box2d.B2Body]int)
+	for _, f := range fixtures {
+		body := f.GetBody()
+		point, distance := nearestPoint(f, c)
+		if distance > r {
+			continue
+		}
+		if opts.Occlusion && occluded(c, point, body) {
+			continue
+		}
+
+		strength := impulse * opts.Falloff.scale(distance, r, opts.MinDistance)
+		dir := box2d.B2Vec2Sub(point, c)
+		if distance == 0 {
+			// the center is inside the fixture
+			point = c
+			dir = box2d.B2Vec2Sub(body.GetWorldCenter(), c)
+		}
+		dir.Normalize()
+		push := box2d.B2Vec2MulScalar(strength, dir)
+		body.ApplyLinearImpulse(push, point, true)
+
+		i, ok := index[body]
+		if !ok {
+			i = len(hits)
+			index[body] = i
+			hit := ExplosionHit{Body: body}
+			hit.EntityID, hit.HasEntityID = body.GetUserData().(uint64)
+			hits = append(hits, hit)
+		}
+		hits[i].Impulse = box2d.B2Vec2Add(hits[i].Impulse, push)
+	}
+	return hits
+}
+
+// nearestPoint returns the point on the fixture nearest to p and how far away
+// it is, in meters. Each edge of a chain is checked.
+func nearestPoint(f *box2d.B2Fixture, p box2d.B2Vec2) (box2d.B2Vec2, float64) {
+	point := box2d.NewB2CircleShape()
+	point.M_radius = 0
+
+	var nearest box2d.B2Vec2
+	distance := math.Inf(1)
+	shape := f.GetShape()
+	for i := 0; i < shape.GetChildCount(); i++ {
+		input := box2d.MakeB2DistanceInput()
+		input.ProxyA.Set(point, 0)
+		input.ProxyB.Set(shape, i)
+		input.TransformA = box2d.MakeB2TransformByPositionAndRotation(p, box2d.MakeB2RotFromAngle(0))
+		input.TransformB = f.GetBody().GetTransform()
+		input.UseRadii = true
+		cache := box2d.MakeB2SimplexCache()
+		output := box2d.MakeB2DistanceOutput()
+		box2d.B2Distance(&output, &cache, &input)
+		if output.Distance < distance {
+			nearest, distance = output.PointB, output.Distance
+		}
+	}
+	return nearest, distance
+}
+
+// occluded checks if a static body other than the target is between from and
+// to.
+func occluded(from, to box2d.B2Vec2, target *box2d.B2Body) bool {
+	if box2d.B2Vec2Sub(to, from).Length() == 0 {
+		return false
+	}
+	blocked := false
+	World.RayCast(func(f *box2d.B2Fixture, point, normal box2d.B2Vec2, fraction float64) float64 {
+		if f.IsSensor() || f.GetBody() == target || f.GetBody().GetType() != box2d.B2BodyType.B2_staticBody {
+			return -1
+		}
+		blocked = true
+		return 0
+	}, from, to)
+	return blocked
+}
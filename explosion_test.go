@@ -0,0 +1,112 @@
+package engoBox2dSystem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+func TestExplode(t *testing.T) {
+	newTestWorld(t, 0)
+
+	right := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	right.SetUserData(uint64(42))
+	left := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: -100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	outside := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 300, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	masked := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 100}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1, Filter: box2d.B2Filter{CategoryBits: 0x0002}})
+	skewed := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: -100}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	skewed.SetTransform(Conv.ToBox2d2Vec(engo.Point{X: 15, Y: -100}), 0)
+
+	hits := Explode(engo.Point{X: 0, Y: 0}, 200, 1, &ExplosionOptions{Mask: 0x0001})
+	if len(hits) != 3 {
+		t.Fatalf("explosion should hit 3 bodies, got: %v", len(hits))
+	}
+	for _, hit := range hits {
+		if hit.Impulse.Length()-1 > 1e-9 {
+			t.Errorf("each body should get an impulse of 1, got: %v", hit.Impulse)
+		}
+		if hit.Body == right && (!hit.HasEntityID || hit.EntityID != 42) {
+			t.Errorf("hit should have the body's entity ID, got: %v, %v", hit.EntityID, hit.HasEntityID)
+		}
+		if hit.Body != right && hit.HasEntityID {
+			t.Errorf("body without an entity should not have an ID")
+		}
+	}
+	if v := right.GetLinearVelocity(); math.Abs(v.X-1) > 1e-9 || v.Y != 0 || right.GetAngularVelocity() != 0 {
+		t.Errorf("right body should be pushed right, got: %v spinning %v", v, right.GetAngularVelocity())
+	}
+	if v := left.GetLinearVelocity(); math.Abs(v.X+1) > 1e-9 || v.Y != 0 {
+		t.Errorf("left body should be pushed left, got: %v", v)
+	}
+	if v := outside.GetLinearVelocity(); v != (box2d.B2Vec2{}) {
+		t.Errorf("body outside the radius should not be pushed, got: %v", v)
+	}
+	if v := masked.GetLinearVelocity(); v != (box2d.B2Vec2{}) {
+		t.Errorf("masked body should not be pushed, got: %v", v)
+	}
+	// pushed at its nearest corner, so it spins
+	if v := skewed.GetLinearVelocity(); v.Y >= 0 || skewed.GetAngularVelocity() == 0 {
+		t.Errorf("skewed body should be pushed up and spun, got: %v spinning %v", v, skewed.GetAngularVelocity())
+	}
+}
+
+func TestExplodeFalloff(t *testing.T) {
+	newTestWorld(t, 0)
+	box := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+
+	for _, test := range []struct {
+		opts *ExplosionOptions
+		want float64
+	}{
+		{nil, 1},
+		// nearest point is 4.5 m away, out of 10, less the polygon's skin
+		{&ExplosionOptions{Falloff: FalloffLinear}, 0.55},
+		{&ExplosionOptions{Falloff: FalloffInverseSquare}, 1 / (4.5 * 4.5)},
+		{&ExplosionOptions{Falloff: FalloffInverseSquare, MinDistance: 180}, 1},
+	} {
+		hits := Explode(engo.Point{X: 0, Y: 0}, 200, 1, test.opts)
+		if len(hits) != 1 || math.Abs(hits[0].Impulse.X-test.want) > 1e-3 {
+			t.Errorf("options %+v, want: %v, got: %v", test.opts, test.want, hits)
+		}
+	}
+
+	// inside the box, it's pushed away from the center
+	box.SetLinearVelocity(box2d.B2Vec2{})
+	hits := Explode(engo.Point{X: 95, Y: 0}, 200, 1, nil)
+	if len(hits) != 1 || math.Abs(hits[0].Impulse.X-1) > 1e-9 {
+		t.Errorf("explosion inside the box should push it away, got: %v", hits)
+	}
+
+	// a chain is pushed once, from its nearest edge, 2 m away
+	clearWorld()
+	chain := box2d.MakeB2ChainShape()
+	chain.CreateChain([]box2d.B2Vec2{{X: 9, Y: -1}, {X: 9, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: -1}}, 4)
+	addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{}, 0, &chain, box2d.B2FixtureDef{Density: 1})
+	hits = Explode(engo.Point{X: 0, Y: 0}, 200, 1, &ExplosionOptions{Falloff: FalloffLinear})
+	if len(hits) != 1 || math.Abs(hits[0].Impulse.X-0.8) > 1e-2 || math.Abs(hits[0].Impulse.Y) > 1e-9 {
+		t.Errorf("chain should be pushed from its nearest edge, want: 0.8, got: %v", hits)
+	}
+}
+
+func TestExplodeOcclusion(t *testing.T) {
+	newTestWorld(t, 0)
+	addStatic(engo.Point{X: 50, Y: 0}, 10, 100, 0)
+	hidden := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	open := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: -100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+
+	hits := Explode(engo.Point{X: 0, Y: 0}, 200, 1, &ExplosionOptions{Occlusion: true})
+	if len(hits) != 1 || hits[0].Body != open {
+		t.Errorf("only the body in the open should be hit, got: %v", hits)
+	}
+	if v := hidden.GetLinearVelocity(); v != (box2d.B2Vec2{}) {
+		t.Errorf("body behind the wall should not be pushed, got: %v", v)
+	}
+
+	hits = Explode(engo.Point{X: 0, Y: 0}, 200, 1, nil)
+	if len(hits) != 2 {
+		t.Errorf("without occlusion both bodies should be hit, got: %v", hits)
+	}
+}
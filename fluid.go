@@ -0,0 +1,165 @@
+package engoBox2dSystem
+
+import (
+	"math"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// fluidCircleSegments is how many sides circles are given when working out
+// how much of them is under the surface.
+const fluidCircleSegments = 16
+
+// FluidVolumeComponent makes the sensor fixtures of its body act like a fluid,
+// such as water or lava. Each step, every dynamic fixture overlapping it is
+// pushed up by the weight of the fluid it displaces, and slowed by drag, at the
+// centroid of the part that's submerged. That way boats tip over and crates bob.
+//
+// Polygon and circle fixtures are supported, on both sides.
+type FluidVolumeComponent struct {
+	// Density of the fluid in kg/m^2. Bodies less dense than this float.
+	Density float64
+	// LinearDrag is the drag force per square meter submerged, for each m/s the
+	// body moves through the fluid.
+	LinearDrag float64
+	// AngularDrag is the drag torque per square meter submerged, for each
+	// radian per second the body spins.
+	AngularDrag float64
+	// Flow is the velocity of the fluid, in pixels per second. Drag pushes
+	// bodies along with it.
+	Flow engo.Point
+}
+
+type fluidEntity struct {
+	*ecs.BasicEntity
+	*FluidVolumeComponent
+	*Box2dComponent
+}
+
+// AddFluid adds a fluid volume to the physics system. The fixtures of the
+// volume's body should be sensors.
+func (b *PhysicsSystem) AddFluid(basic *ecs.BasicEntity, fluid *FluidVolumeComponent, box *Box2dComponent) {
+	b.fluids = append(b.fluids, fluidEntity{basic, fluid, box})
+}
+
+// applyFluids applies the buoyancy and drag forces of every fluid volume.
+func (b *PhysicsSystem) applyFluids() {
+	for _, fl := range b.fluids {
+		for ce := fl.Body.GetContactList(); ce != nil; ce = ce.Next {
+			c := ce.Contact
+			if !c.IsTouching() {
+				continue
+			}
+			fluid, other := c.GetFixtureA(), c.GetFixtureB()
+			if other.GetBody() == fl.Body {
+				fluid, other = other, fluid
+			}
+			fl.push(fluid, other)
+		}
+	}
+}
+
+// push applies the buoyancy and drag of one of the fluid's fixtures to the
+// other fixture.
+func (fl fluidEntity) push(fluid, other *box2d.B2Fixture) {
+	body := other.GetBody()
+	if other.IsSensor() || body.GetType() != box2d.B2BodyType.B2_dynamicBody {
+		return
+	}
+	area, centroid := submerged(fixturePolygon(other), fixturePolygon(fluid))
+	if area <= 0 {
+		return
+	}
+
+	buoyancy := box2d.B2Vec2MulScalar(-fl.Density*area, body.GetWorld().GetGravity())
+	body.ApplyForce(buoyancy, centroid, true)
+
+	v := box2d.B2Vec2Sub(body.GetLinearVelocityFromWorldPoint(centroid), Conv.ToBox2d2Vec(fl.Flow))
+	drag := box2d.B2Vec2MulScalar(-fl.LinearDrag*area, v)
+	body.ApplyForce(drag, centroid, true)
+	body.ApplyTorque(-fl.AngularDrag*area*body.GetAngularVelocity(), true)
+}
+
+// fixturePolygon returns the outline of the fixture in world coordinates,
+// counter-clockwise. Circles are turned into polygons; other shapes have no
+// area and return nil.
+func fixturePolygon(f *box2d.B2Fixture) []box2d.B2Vec2 {
+	xf := f.GetBody().GetTransform()
+	switch s := f.GetShape().(type) {
+	case *box2d.B2PolygonShape:
+		poly := make([]box2d.B2Vec2, s.M_count)
+		for i := range poly {
+			poly[i] = box2d.B2TransformVec2Mul(xf, s.M_vertices[i])
+		}
+		return poly
+	case *box2d.B2CircleShape:
+		center := box2d.B2TransformVec2Mul(xf, s.M_p)
+		poly := make([]box2d.B2Vec2, fluidCircleSegments)
+		for i := range poly {
+			sin, cos := math.Sincos(2 * math.Pi * float64(i) / fluidCircleSegments)
+			poly[i] = box2d.B2Vec2{X: center.X + s.M_radius*cos, Y: center.Y + s.M_radius*sin}
+		}
+		return poly
+	}
+	return nil
+}
+
+// submerged returns the area and centroid of the part of poly inside the
+// convex polygon fluid.
+func submerged(poly, fluid []box2d.B2Vec2) (float64, box2d.B2Vec2) {
+	for i := range fluid {
+		if len(poly) == 0 {
+			break
+		}
+		a, b := fluid[i], fluid[(i+1)%len(fluid)]
+		edge := box2d.B2Vec2Sub(b, a)
+		inside := func(p box2d.B2Vec2) bool {
+			return box2d.B2Vec2Cross(edge, box2d.B2Vec2Sub(p, a)) >= 0
+		}
+		var clipped []box2d.B2Vec2
+		for j := range poly {
+			p, q := poly[j], poly[(j+1)%len(poly)]
+			if inside(p) {
+				clipped = append(clipped, p)
+			}
+			if inside(p) != inside(q) {
+				clipped = append(clipped, intersect(p, q, a, b))
+			}
+		}
+		poly = clipped
+	}
+	return polygonCentroid(poly)
+}
+
+// intersect returns where the segment p-q crosses the line through a and b.
+func intersect(p, q, a, b box2d.B2Vec2) box2d.B2Vec2 {
+	edge := box2d.B2Vec2Sub(b, a)
+	dp := box2d.B2Vec2Cross(edge, box2d.B2Vec2Sub(p, a))
+	dq := box2d.B2Vec2Cross(edge, box2d.B2Vec2Sub(q, a))
+	t := dp / (dp - dq)
+	return box2d.B2Vec2Add(p, box2d.B2Vec2MulScalar(t, box2d.B2Vec2Sub(q, p)))
+}
+
+// polygonCentroid returns the area and centroid of a counter-clockwise polygon.
+func polygonCentroid(poly []box2d.B2Vec2) (float64, box2d.B2Vec2) {
+	if len(poly) < 3 {
+		return 0, box2d.B2Vec2{}
+	}
+	var area float64
+	var centroid box2d.B2Vec2
+	origin := poly[0]
+	for i := 1; i < len(poly)-1; i++ {
+		e1 := box2d.B2Vec2Sub(poly[i], origin)
+		e2 := box2d.B2Vec2Sub(poly[i+1], origin)
+		a := 0.5 * box2d.B2Vec2Cross(e1, e2)
+		area += a
+		centroid = box2d.B2Vec2Add(centroid, box2d.B2Vec2MulScalar(a/3, box2d.B2Vec2Add(e1, e2)))
+	}
+	if area <= 0 {
+		return 0, box2d.B2Vec2{}
+	}
+	return area, box2d.B2Vec2Add(origin, box2d.B2Vec2MulScalar(1/area, centroid))
+}
@@ -0,0 +1,126 @@
+package engoBox2dSystem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+)
+
+func TestSubmerged(t *testing.T) {
+	square := []box2d.B2Vec2{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}, {X: 0, Y: 2}}
+	water := []box2d.B2Vec2{{X: -10, Y: 1}, {X: 10, Y: 1}, {X: 10, Y: 10}, {X: -10, Y: 10}}
+
+	area, centroid := submerged(square, water)
+	if math.Abs(area-2) > 1e-9 || box2d.B2Vec2Sub(centroid, box2d.B2Vec2{X: 1, Y: 1.5}).Length() > 1e-9 {
+		t.Errorf("half submerged square, want: 2 at (1, 1.5), got: %v at %v", area, centroid)
+	}
+
+	area, _ = submerged(square, []box2d.B2Vec2{{X: 5, Y: 5}, {X: 6, Y: 5}, {X: 6, Y: 6}})
+	if area != 0 {
+		t.Errorf("square outside the fluid should have no area submerged, got: %v", area)
+	}
+}
+
+func TestFluidBuoyancy(t *testing.T) {
+	newTestWorld(t, 10)
+	sys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+
+	// a pool of water 1000x200 pixels with its surface at y = 100
+	water := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 0, Y: 200}, 0, testBox(1000, 200), box2d.B2FixtureDef{IsSensor: true})
+	basic := ecs.NewBasic()
+	sys.AddFluid(&basic, &FluidVolumeComponent{Density: 1, LinearDrag: 2, AngularDrag: 1}, &Box2dComponent{Body: water})
+
+	circle := box2d.NewB2CircleShape()
+	circle.M_radius = 0.5
+	var spaces []*common.SpaceComponent
+	for _, floater := range []struct {
+		center  engo.Point
+		shape   box2d.B2ShapeInterface
+		density float64
+	}{
+		{engo.Point{X: -100, Y: 80}, testBox(20, 20), 0.5},
+		{engo.Point{X: 0, Y: 80}, circle, 0.5},
+		{engo.Point{X: 100, Y: 80}, testBox(20, 20), 2},
+	} {
+		body := addTestBody(box2d.B2BodyType.B2_dynamicBody, floater.center, 0, floater.shape, box2d.B2FixtureDef{Density: floater.density})
+		space := &common.SpaceComponent{}
+		space.SetCenter(floater.center)
+		basic := ecs.NewBasic()
+		sys.Add(&basic, space, &Box2dComponent{Body: body})
+		spaces = append(spaces, space)
+	}
+	crate, ball, rock := spaces[0], spaces[1], spaces[2]
+
+	stepFrames(600, sys.Update)
+	// half as dense as the water, so they float half under
+	if y := crate.Center().Y; math.Abs(float64(y-100)) > 1 {
+		t.Errorf("crate should float half submerged at 100, got: %v", y)
+	}
+	// the circle is a polygon under water, so it's a bit smaller
+	if y := ball.Center().Y; math.Abs(float64(y-100)) > 1 {
+		t.Errorf("ball should float half submerged at 100, got: %v", y)
+	}
+	if y := rock.Center().Y; y < 300 {
+		t.Errorf("rock should sink out of the bottom of the pool, got: %v", y)
+	}
+}
+
+func TestFluidTipping(t *testing.T) {
+	newTestWorld(t, 10)
+	sys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+
+	water := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 0, Y: 200}, 0, testBox(1000, 200), box2d.B2FixtureDef{IsSensor: true})
+	basic := ecs.NewBasic()
+	sys.AddFluid(&basic, &FluidVolumeComponent{Density: 1, LinearDrag: 2, AngularDrag: 1}, &Box2dComponent{Body: water})
+
+	plank := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 100}, 30, testBox(80, 10), box2d.B2FixtureDef{Density: 0.5})
+	plankSpace := &common.SpaceComponent{Rotation: 30}
+	plankSpace.SetCenter(engo.Point{X: 0, Y: 100})
+	plankBasic := ecs.NewBasic()
+	sys.Add(&plankBasic, plankSpace, &Box2dComponent{Body: plank})
+
+	mast := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 100, Y: 100}, 5, testBox(10, 80), box2d.B2FixtureDef{Density: 0.5})
+	mastSpace := &common.SpaceComponent{Rotation: 5}
+	mastSpace.SetCenter(engo.Point{X: 100, Y: 100})
+	mastBasic := ecs.NewBasic()
+	sys.Add(&mastBasic, mastSpace, &Box2dComponent{Body: mast})
+
+	stepFrames(600, sys.Update)
+	if s := math.Sin(plank.GetAngle()); math.Abs(s) > 0.01 {
+		t.Errorf("plank should right itself to lie flat, angle: %v", Conv.RadToDeg(plank.GetAngle()))
+	}
+	if c := math.Cos(mast.GetAngle()); math.Abs(c) > 0.02 {
+		t.Errorf("mast should tip over onto its side, angle: %v", Conv.RadToDeg(mast.GetAngle()))
+	}
+}
+
+func TestFluidFlow(t *testing.T) {
+	newTestWorld(t, 10)
+	sys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+
+	water := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 0, Y: 200}, 0, testBox(1000, 200), box2d.B2FixtureDef{IsSensor: true})
+	waterBasic := ecs.NewBasic()
+	fluid := &FluidVolumeComponent{Density: 1, LinearDrag: 2, AngularDrag: 1, Flow: engo.Point{X: 100, Y: 0}}
+	sys.AddFluid(&waterBasic, fluid, &Box2dComponent{Body: water})
+
+	crate := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: -400, Y: 100}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 0.5})
+	space := &common.SpaceComponent{}
+	space.SetCenter(engo.Point{X: -400, Y: 100})
+	basic := ecs.NewBasic()
+	sys.Add(&basic, space, &Box2dComponent{Body: crate})
+
+	stepFrames(300, sys.Update)
+	if v := Conv.ToEngoPoint(crate.GetLinearVelocity()).X; math.Abs(float64(v-100)) > 1 {
+		t.Errorf("crate should drift with the flow, want: 100, got: %v", v)
+	}
+
+	sys.Remove(waterBasic)
+	if len(sys.fluids) != 0 {
+		t.Errorf("fluid should have been removed")
+	}
+}
@@ -0,0 +1,188 @@
+package engoBox2dSystem
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// GamepadCursorPriority makes sure the cursor moves before the MouseSystem
+// reads it.
+const GamepadCursorPriority = MouseSystemPriority + 1
+
+// GamepadState is what the GamepadCursor reads from the gamepad each frame.
+type GamepadState struct {
+	// X and Y of the stick, from -1 to 1.
+	X, Y float32
+	// Left and Right are whether the buttons for the left and right clicks are
+	// held.
+	Left, Right bool
+}
+
+// GamepadCursor is a virtual cursor moved with a gamepad's stick, for builds
+// without a mouse. It's a PointerSource for the MouseSystem, so entities are
+// Hovered, Clicked and Dragged just like with the mouse. Add it to the
+// ecs.World after the MouseSystem, and it'll set itself as its Source.
+type GamepadCursor struct {
+	// Gamepad is read each frame. The left stick moves the cursor, A is the
+	// left click and B is the right click.
+	Gamepad *engo.Gamepad
+	// Read, if set, is used instead of the Gamepad, for other layouts, AIs, or
+	// tests.
+	Read func() GamepadState
+
+	// Position of the cursor in window coordinates.
+	Position engo.Point
+	// MaxSpeed in pixels per second of the cursor with the stick all the way
+	// over. Defaults to 600.
+	MaxSpeed float32
+	// Acceleration of the cursor in pixels per second squared. Defaults to
+	// 3000.
+	Acceleration float32
+	// DeadZone is how far the stick has to move before the cursor does.
+	// Defaults to 0.2.
+	DeadZone float32
+
+	// Snap jumps the cursor to the nearest entity of the MouseSystem in the
+	// direction the stick is flicked, if there's one within SnapAngle degrees.
+	// If there isn't, the cursor moves like normal.
+	Snap bool
+	// SnapAngle defaults to 30 degrees either side of the stick.
+	SnapAngle float32
+
+	mouse    *MouseSystem
+	velocity engo.Point
+	last     GamepadState
+	snapped  bool
+	pointer  Pointer
+}
+
+// Priority implements the ecs.Prioritizer interface.
+func (c *GamepadCursor) Priority() int { return GamepadCursorPriority }
+
+// New sets the cursor as the MouseSystem's Source.
+func (c *GamepadCursor) New(w *ecs.World) {
+	for _, system := range w.Systems() {
+		switch sys := system.(type) {
+		case *MouseSystem:
+			c.mouse = sys
+			sys.Source = c
+		}
+	}
+}
+
+// Remove doesn't do anything, since the cursor has no entities.
+func (c *GamepadCursor) Remove(basic ecs.BasicEntity) {}
+
+// Pointer implements the PointerSource interface
+func (c *GamepadCursor) Pointer() Pointer { return c.pointer }
+
+// Update moves the cursor and works out what the buttons did.
+func (c *GamepadCursor) Update(dt float32) {
+	state := c.read()
+	stick := engo.Point{X: state.X, Y: state.Y}
+	deadZone := defaultFloat(c.DeadZone, 0.2)
+	length := stick.PointDistance(engo.Point{})
+	if length <= deadZone {
+		stick = engo.Point{}
+		c.snapped = false
+	} else {
+		// rescale so the stick starts from zero just past the dead zone
+		stick.MultiplyScalar(math.Min(1, (length-deadZone)/(1-deadZone)) / length)
+	}
+
+	start := c.Position
+	if c.Snap && !c.snapped && length > deadZone {
+		if target, ok := c.snapTarget(stick); ok {
+			c.Position = target
+			c.velocity = engo.Point{}
+			c.snapped = true
+		}
+	}
+	if !c.snapped {
+		c.move(stick, dt)
+	}
+
+	// a Pointer only has room for one button change, so if both buttons
+	// changed, the right one waits for the next frame
+	p := Pointer{X: c.Position.X, Y: c.Position.Y, Action: engo.Neutral, Button: engo.MouseButtonLeft}
+	switch {
+	case state.Left != c.last.Left:
+		p.Action = buttonAction(state.Left)
+		c.last.Left = state.Left
+	case state.Right != c.last.Right:
+		p.Action, p.Button = buttonAction(state.Right), engo.MouseButtonRight
+		c.last.Right = state.Right
+	case c.Position != start:
+		p.Action = engo.Move
+		if c.last.Right && !c.last.Left {
+			p.Button = engo.MouseButtonRight
+		}
+	}
+	c.pointer = p
+}
+
+// buttonAction is the action for a button that's changed to down or up.
+func buttonAction(down bool) engo.Action {
+	if down {
+		return engo.Press
+	}
+	return engo.Release
+}
+
+func (c *GamepadCursor) read() GamepadState {
+	if c.Read != nil {
+		return c.Read()
+	}
+	if c.Gamepad == nil {
+		return GamepadState{}
+	}
+	return GamepadState{
+		X:     c.Gamepad.LeftX.Value(),
+		Y:     c.Gamepad.LeftY.Value(),
+		Left:  c.Gamepad.A.JustPressed() || c.Gamepad.A.Down(),
+		Right: c.Gamepad.B.JustPressed() || c.Gamepad.B.Down(),
+	}
+}
+
+// move speeds the cursor up or down toward the stick's speed, and moves it.
+func (c *GamepadCursor) move(stick engo.Point, dt float32) {
+	maxSpeed := defaultFloat(c.MaxSpeed, 600)
+	target := engo.Point{X: stick.X * maxSpeed, Y: stick.Y * maxSpeed}
+	diff := engo.Point{X: target.X - c.velocity.X, Y: target.Y - c.velocity.Y}
+	step := defaultFloat(c.Acceleration, 3000) * dt
+	if d := diff.PointDistance(engo.Point{}); d > step {
+		diff.MultiplyScalar(step / d)
+	}
+	c.velocity.Add(diff)
+	c.Position.X = math.Max(0, math.Min(c.Position.X+c.velocity.X*dt, engo.GameWidth()))
+	c.Position.Y = math.Max(0, math.Min(c.Position.Y+c.velocity.Y*dt, engo.GameHeight()))
+}
+
+// snapTarget finds the nearest entity of the MouseSystem in the direction of
+// the stick, in window coordinates. Entities the MouseSystem skips, or that are
+// disabled, aren't snapped to.
+func (c *GamepadCursor) snapTarget(stick engo.Point) (engo.Point, bool) {
+	if c.mouse == nil {
+		return engo.Point{}, false
+	}
+	dir, _ := stick.Normalize()
+	cos := math.Cos(defaultFloat(c.SnapAngle, 30) * math.Pi / 180)
+	var best engo.Point
+	bestDistance := float32(-1)
+	for _, e := range c.mouse.entities {
+		if e.SpaceComponent == nil || e.Box2dComponent == nil || e.Body == nil || e.hidden() || e.MouseComponent.Disabled {
+			continue
+		}
+		target := c.mouse.toWindow(c.mouse.hitCenter(e))
+		to := engo.Point{X: target.X - c.Position.X, Y: target.Y - c.Position.Y}
+		toDir, d := to.Normalize()
+		if d < 1 || toDir.X*dir.X+toDir.Y*dir.Y < cos {
+			continue
+		}
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = target, d
+		}
+	}
+	return best, bestDistance >= 0
+}
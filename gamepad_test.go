@@ -0,0 +1,232 @@
+package engoBox2dSystem
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+)
+
+func gamepadTestCursor(state *GamepadState) *GamepadCursor {
+	c := &GamepadCursor{Read: func() GamepadState { return *state }, mouse: sys}
+	sys.Source = c
+	return c
+}
+
+func TestGamepadCursorMove(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{0})
+
+	state := GamepadState{X: 0.1}
+	c := gamepadTestCursor(&state)
+	c.Position = engo.Point{X: 10, Y: 50}
+
+	c.Update(updateTime)
+	if c.Position.X != 10 || c.Pointer().Action != engo.Neutral {
+		t.Errorf("Cursor should not move inside the dead zone, got: %v", c.Position)
+	}
+
+	state.X = 1
+	c.Update(updateTime)
+	first := c.Position.X - 10
+	if want := 3000 * updateTime * updateTime; first < want-0.001 || first > want+0.001 {
+		t.Errorf("Cursor should speed up with the acceleration, moved: %v, want: %v", first, want)
+	}
+	if c.Pointer().Action != engo.Move {
+		t.Errorf("Moving the cursor should be a move action, got: %v", c.Pointer().Action)
+	}
+	for i := 0; i < 30; i++ {
+		c.Update(updateTime)
+	}
+	if c.velocity.X != 600 {
+		t.Errorf("Cursor should top out at MaxSpeed, got: %v", c.velocity.X)
+	}
+	if c.Position.X != engo.GameWidth() {
+		t.Errorf("Cursor should stay in the window, got: %v", c.Position.X)
+	}
+
+	state.X = 0
+	for i := 0; i < 30; i++ {
+		c.Update(updateTime)
+	}
+	if c.velocity.X != 0 {
+		t.Errorf("Cursor should stop once the stick is let go, got: %v", c.velocity.X)
+	}
+}
+
+func TestGamepadCursorClick(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{1})
+
+	state := GamepadState{}
+	c := gamepadTestCursor(&state)
+	c.Position = engo.Point{X: 5, Y: 5}
+	e := sys.entities[0]
+
+	c.Update(updateTime)
+	sys.Update(updateTime)
+	if !e.Hovered {
+		t.Errorf("Entity should be hovered by the cursor")
+	}
+
+	state.Left = true
+	c.Update(updateTime)
+	sys.Update(updateTime)
+	if !e.Clicked {
+		t.Errorf("Entity should be clicked by the cursor")
+	}
+
+	state.X = 0.5
+	c.Update(updateTime)
+	sys.Update(updateTime)
+	if !e.Dragged {
+		t.Errorf("Entity should be dragged by the cursor")
+	}
+
+	state.Left = false
+	c.Update(updateTime)
+	sys.Update(updateTime)
+	if !e.Released || e.Dragged {
+		t.Errorf("Entity should be released by the cursor")
+	}
+
+	state = GamepadState{Right: true}
+	c.Position = engo.Point{X: 5, Y: 5}
+	c.Update(updateTime)
+	sys.Update(updateTime)
+	if !e.RightClicked {
+		t.Errorf("Entity should be right clicked by the cursor")
+	}
+
+	// both buttons changing at once are reported one frame after the other
+	for _, test := range []struct {
+		state  GamepadState
+		action engo.Action
+		button engo.MouseButton
+	}{
+		{GamepadState{Left: true}, engo.Press, engo.MouseButtonLeft},
+		{GamepadState{Left: true}, engo.Release, engo.MouseButtonRight},
+		{GamepadState{Left: true}, engo.Neutral, engo.MouseButtonLeft},
+		{GamepadState{Right: true}, engo.Release, engo.MouseButtonLeft},
+		{GamepadState{Right: true}, engo.Press, engo.MouseButtonRight},
+	} {
+		state = test.state
+		c.Update(updateTime)
+		if p := c.Pointer(); p.Action != test.action || p.Button != test.button {
+			t.Errorf("Wrong button change reported for %+v, want: %v %v, got: %v %v", test.state, test.action, test.button, p.Action, p.Button)
+		}
+	}
+}
+
+func TestGamepadCursorSnap(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{3})
+
+	state := GamepadState{}
+	c := gamepadTestCursor(&state)
+	c.Snap = true
+	c.Position = engo.Point{X: 5, Y: 5}
+
+	state.X = 0.5
+	c.Update(updateTime)
+	if c.Position.X < 24.9 || c.Position.X > 25.1 || c.Position.Y < 4.9 || c.Position.Y > 5.1 {
+		t.Errorf("Cursor should snap to the next entity, got: %v", c.Position)
+	}
+	for i := 0; i < 10; i++ {
+		c.Update(updateTime)
+	}
+	if c.Position.X < 24.9 || c.Position.X > 25.1 {
+		t.Errorf("Cursor should stay put until the stick is let go, got: %v", c.Position)
+	}
+
+	state.X = 0
+	c.Update(updateTime)
+	state.X = 0.5
+	c.Update(updateTime)
+	if c.Position.X < 44.9 || c.Position.X > 45.1 {
+		t.Errorf("Cursor should snap to the entity after, got: %v", c.Position)
+	}
+
+	state.X = 0
+	c.Update(updateTime)
+	state.Y = 1
+	c.Update(updateTime)
+	if c.Position.X < 44.9 || c.Position.X > 45.1 || c.Position.Y <= 5 || c.Position.Y > 6 {
+		t.Errorf("Cursor should move like normal with nothing to snap to, got: %v", c.Position)
+	}
+
+	// entities that can't be clicked are passed over
+	flick := func() {
+		state = GamepadState{}
+		c.Update(updateTime)
+		c.Position = engo.Point{X: 5, Y: 5}
+		state.X = 0.5
+		c.Update(updateTime)
+	}
+	sys.entities[1].MouseComponent.Disabled = true
+	flick()
+	if c.Position.X < 44.9 || c.Position.X > 45.1 {
+		t.Errorf("Cursor should snap past a disabled entity, got: %v", c.Position)
+	}
+	sys.entities[2].MouseComponent.Hidden = true
+	flick()
+	if c.Position.X > 6 {
+		t.Errorf("Cursor should not snap to a hidden entity, got: %v", c.Position)
+	}
+	sys.entities[2].MouseComponent.Hidden = false
+	sys.entities[2].RenderComponent = &common.RenderComponent{Hidden: true}
+	flick()
+	if c.Position.X > 6 {
+		t.Errorf("Cursor should not snap to an entity with a hidden RenderComponent, got: %v", c.Position)
+	}
+}
+
+func TestGamepadCursorSnapHitTest(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{2})
+
+	state := GamepadState{}
+	c := gamepadTestCursor(&state)
+	c.Snap = true
+	// the body is somewhere else than the entity is drawn
+	sys.entities[1].Body.SetTransform(Conv.ToBox2d2Vec(engo.Point{X: 25, Y: 50}), 0)
+
+	for _, test := range []struct {
+		mode  HitTestMode
+		stick GamepadState
+		want  engo.Point
+	}{
+		{HitTestSpace, GamepadState{X: 0.5}, engo.Point{X: 25, Y: 5}},
+		{HitTestBody, GamepadState{X: 0.2, Y: 0.45}, engo.Point{X: 25, Y: 50}},
+	} {
+		sys.HitTest = test.mode
+		state = GamepadState{}
+		c.Update(updateTime)
+		c.Position = engo.Point{X: 5, Y: 5}
+		state = test.stick
+		c.Update(updateTime)
+		if c.Position.PointDistance(test.want) > 0.1 {
+			t.Errorf("Cursor should snap to where mode %v hit tests, want: %v, got: %v", test.mode, test.want, c.Position)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package engoBox2dSystem
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"github.com/ByteArena/box2d"
+)
+
+// StepHashMessage is sent out after each step of the World when the
+// PhysicsSystem has HashSteps set. Two Worlds that were stepped the same way
+// send out the same hashes, so they can be compared to find where they drifted
+// apart. Steps that are re-simulated after a Rewind are sent out again with
+// their new hashes.
+type StepHashMessage struct {
+	// Step is the number of Updates the system ran before this one.
+	Step uint64
+	// Hash is the hash of the whole World.
+	Hash uint64
+	// Bodies are the hashes of each body, in the order they were created.
+	Bodies []BodyHash
+}
+
+// Type implements the engo.Message interface
+func (StepHashMessage) Type() string { return "StepHashMessage" }
+
+// BodyHash is the hash of a single body's position, angle, velocities, and
+// contacts.
+type BodyHash struct {
+	// EntityID is the body's user data if it's an entity ID.
+	EntityID    uint64
+	HasEntityID bool
+	Hash        uint64
+}
+
+// Desync is where two lists of StepHashMessages first differ.
+type Desync struct {
+	Step uint64
+	// Bodies are the indexes of the bodies whose hashes differed. If one World
+	// has more bodies than the other, the extra ones are included.
+	Bodies []int
+}
+
+// CompareStepHashes finds the first step that is in both a and b and has a
+// different hash. If a step is in a list more than once, such as after a
+// Rewind, the last one is used. It returns nil if no steps differ.
+func CompareStepHashes(a, b []StepHashMessage) *Desync {
+	byStep := func(msgs []StepHashMessage) map[uint64]StepHashMessage {
+		m := make(map[uint64]StepHashMessage, len(msgs))
+		for _, msg := range msgs {
+			m[msg.Step] = msg
+		}
+		return m
+	}
+	as, bs := byStep(a), byStep(b)
+	steps := make([]uint64, 0, len(as))
+	for step := range as {
+		if _, ok := bs[step]; ok {
+			steps = append(steps, step)
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i] < steps[j] })
+
+	for _, step := range steps {
+		ma, mb := as[step], bs[step]
+		if ma.Hash == mb.Hash {
+			continue
+		}
+		d := &Desync{Step: step}
+		for i := 0; i < len(ma.Bodies) || i < len(mb.Bodies); i++ {
+			if i >= len(ma.Bodies) || i >= len(mb.Bodies) || ma.Bodies[i] != mb.Bodies[i] {
+				d.Bodies = append(d.Bodies, i)
+			}
+		}
+		return d
+	}
+	return nil
+}
+
+// HashWorld hashes the state of every body in the World.
+func HashWorld() (uint64, []BodyHash) {
+	bodies := worldBodies()
+	index := make(map[*box2d.B2Body]int, len(bodies))
+	for i, bod := range bodies {
+		index[bod] = i
+	}
+
+	world := fnv.New64a()
+	hashes := make([]BodyHash, len(bodies))
+	var buf [8]byte
+	for i, bod := range bodies {
+		h := fnv.New64a()
+		putFloat := func(f float64) {
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+			h.Write(buf[:])
+		}
+		putInt := func(n int) {
+			binary.LittleEndian.PutUint64(buf[:], uint64(n))
+			h.Write(buf[:])
+		}
+		xf := bod.GetTransform()
+		putFloat(xf.P.X)
+		putFloat(xf.P.Y)
+		putFloat(bod.GetAngle())
+		v := bod.GetLinearVelocity()
+		putFloat(v.X)
+		putFloat(v.Y)
+		putFloat(bod.GetAngularVelocity())
+		if bod.IsAwake() {
+			putInt(1)
+		} else {
+			putInt(0)
+		}
+		for ce := bod.GetContactList(); ce != nil; ce = ce.Next {
+			c := ce.Contact
+			if !c.IsTouching() {
+				continue
+			}
+			putInt(index[ce.Other])
+			putInt(c.GetChildIndexA())
+			putInt(c.GetChildIndexB())
+			m := c.GetManifold()
+			putInt(m.PointCount)
+			for p := 0; p < m.PointCount; p++ {
+				putFloat(m.Points[p].NormalImpulse)
+				putFloat(m.Points[p].TangentImpulse)
+			}
+		}
+
+		hashes[i].Hash = h.Sum64()
+		if id, ok := bod.GetUserData().(uint64); ok {
+			hashes[i].EntityID, hashes[i].HasEntityID = id, true
+		}
+		binary.LittleEndian.PutUint64(buf[:], hashes[i].Hash)
+		world.Write(buf[:])
+	}
+	return world.Sum64(), hashes
+}
@@ -0,0 +1,99 @@
+package engoBox2dSystem
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// runHashedStack simulates the test stack and returns the step hashes. If nudge
+// is positive, the ball is pushed right before that Update.
+func runHashedStack(t *testing.T, nudge int) []StepHashMessage {
+	clearWorld()
+	var msgs []StepHashMessage
+	newTestMailbox(t)
+	engo.Mailbox.Listen("StepHashMessage", func(m engo.Message) {
+		msgs = append(msgs, m.(StepHashMessage))
+	})
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3, HashSteps: true}
+	entities := newTestStack(phys)
+	ball := entities[len(entities)-1]
+	for i := 0; i < 30; i++ {
+		if i == nudge {
+			ball.Body.ApplyLinearImpulseToCenter(box2d.B2Vec2{X: 0.01, Y: 0}, true)
+		}
+		phys.Update(1.0 / 60.0)
+	}
+	return msgs
+}
+
+func TestStepHashes(t *testing.T) {
+	defer clearWorld()
+
+	first := runHashedStack(t, -1)
+	if len(first) != 30 {
+		t.Fatalf("wrong number of hash messages, want: %d, got: %d", 30, len(first))
+	}
+	for i, msg := range first {
+		if msg.Step != uint64(i) {
+			t.Errorf("hash message has wrong step, want: %d, got: %d", i, msg.Step)
+		}
+		if len(msg.Bodies) != 7 {
+			t.Errorf("hash message has wrong number of bodies, want: %d, got: %d", 7, len(msg.Bodies))
+		}
+	}
+	if first[0].Hash == first[1].Hash {
+		t.Error("hash did not change as the world moved")
+	}
+
+	if d := CompareStepHashes(first, runHashedStack(t, -1)); d != nil {
+		t.Errorf("identical runs should have the same hashes, got desync: %+v", d)
+	}
+
+	d := CompareStepHashes(first, runHashedStack(t, 20))
+	if d == nil {
+		t.Fatal("nudging the ball did not change the hashes")
+	}
+	if d.Step != 20 {
+		t.Errorf("wrong desync step, want: %d, got: %d", 20, d.Step)
+	}
+	var ball bool
+	for _, i := range d.Bodies {
+		switch i {
+		case 6:
+			ball = true
+		case 1, 2, 3, 4, 5:
+			t.Errorf("body %d should not have desynced", i)
+		}
+	}
+	if !ball {
+		t.Errorf("the ball should have desynced, got: %v", d.Bodies)
+	}
+}
+
+func TestCompareStepHashes(t *testing.T) {
+	a := []StepHashMessage{
+		{Step: 0, Hash: 1, Bodies: []BodyHash{{Hash: 1}}},
+		{Step: 1, Hash: 2, Bodies: []BodyHash{{Hash: 2}}},
+		{Step: 2, Hash: 3, Bodies: []BodyHash{{Hash: 3}}},
+	}
+	b := []StepHashMessage{
+		{Step: 1, Hash: 5, Bodies: []BodyHash{{Hash: 5}}},
+		{Step: 2, Hash: 6, Bodies: []BodyHash{{Hash: 3}, {Hash: 7}}},
+		// a replayed step replaces the first one
+		{Step: 1, Hash: 2, Bodies: []BodyHash{{Hash: 2}}},
+	}
+	d := CompareStepHashes(a, b)
+	if d == nil {
+		t.Fatal("CompareStepHashes did not find the desync")
+	}
+	if d.Step != 2 || len(d.Bodies) != 1 || d.Bodies[0] != 1 {
+		t.Errorf("wrong desync, want: step 2 bodies [1], got: %+v", d)
+	}
+	if d = CompareStepHashes(a, a[:1]); d != nil {
+		t.Errorf("matching steps should not desync, got: %+v", d)
+	}
+}
@@ -0,0 +1,80 @@
+package engoBox2dSystem
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// clearWorld destroys every body in World, starts over with a new World, and
+// forgets everything the package kept about the old one. Reusing the old World
+// would reuse its broad-phase proxies, which changes the order contacts are
+// solved in. Package state that refers to World has to be reset here.
+func clearWorld() {
+	for b := World.GetBodyList(); b != nil; {
+		next := b.GetNext()
+		World.DestroyBody(b)
+		b = next
+	}
+	World = box2d.MakeB2World(box2d.B2Vec2{})
+	sceneData = newSceneLoader()
+	listOfBodiesToRemove = nil
+	ignoringEffectors = make(map[*box2d.B2Body]bool)
+}
+
+// newTestWorld clears World for the test, with gravity pulling down in meters
+// per second squared, and clears it again when the test is done.
+func newTestWorld(t testing.TB, gravity float64) {
+	clearWorld()
+	World.SetGravity(box2d.B2Vec2{X: 0, Y: gravity})
+	t.Cleanup(clearWorld)
+}
+
+// newTestMailbox gives the test an empty engo.Mailbox, and puts the old one
+// back when the test is done.
+func newTestMailbox(t testing.TB) {
+	mailbox := engo.Mailbox
+	engo.Mailbox = &engo.MessageManager{}
+	t.Cleanup(func() { engo.Mailbox = mailbox })
+}
+
+// addTestBody adds a body centered at the point, turned by the angle in
+// degrees, with one fixture of the shape and the rest of fd.
+func addTestBody(bodyType uint8, center engo.Point, angle float32, shape box2d.B2ShapeInterface, fd box2d.B2FixtureDef) *box2d.B2Body {
+	def := box2d.NewB2BodyDef()
+	def.Type = bodyType
+	def.Position = Conv.ToBox2d2Vec(center)
+	def.Angle = Conv.DegToRad(angle)
+	body := World.CreateBody(def)
+	fd.Shape = shape
+	body.CreateFixtureFromDef(&fd)
+	return body
+}
+
+// testBox is a w by h pixel box shape.
+func testBox(w, h float32) *box2d.B2PolygonShape {
+	shape := box2d.NewB2PolygonShape()
+	shape.SetAsBox(Conv.PxToMeters(w/2), Conv.PxToMeters(h/2))
+	return shape
+}
+
+// addStatic adds a static box with the center, size, and angle in degrees
+func addStatic(center engo.Point, w, h, angle float32) *box2d.B2Body {
+	return addTestBody(box2d.B2BodyType.B2_staticBody, center, angle, testBox(w, h), box2d.B2FixtureDef{Friction: 0.5})
+}
+
+// stepFrames calls the updates in order each frame, at 60 frames a second.
+func stepFrames(frames int, updates ...func(dt float32)) {
+	for i := 0; i < frames; i++ {
+		for _, update := range updates {
+			update(1.0 / 60.0)
+		}
+	}
+}
+
+// stepWorld steps World for systems tested without a PhysicsSystem.
+func stepWorld(dt float32) {
+	World.Step(float64(dt), 8, 3)
+}
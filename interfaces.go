@@ -39,6 +39,15 @@ type Mouseable interface {
 	Box2dFace
 }
 
+// Pickable is for the MouseSystem's AddByInterface, for entities that aren't
+// rendered, like invisible touch targets. Mouseable entities are Pickable too.
+type Pickable interface {
+	common.BasicFace
+	MouseFace
+	common.SpaceFace
+	Box2dFace
+}
+
 // Physicsable is for the PhysicsSystem's AddByInterface
 type Physicsable interface {
 	common.BasicFace
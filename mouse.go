@@ -1,6 +1,7 @@
 package engoBox2dSystem
 
 import (
+	"errors"
 	"log"
 
 	"github.com/EngoEngine/ecs"
@@ -14,6 +15,10 @@ import (
 // MouseSystemPriority ensures the mouse system is updated before any other systems
 const MouseSystemPriority = 100
 
+// ErrNoCamera is the MouseSystem's Err until it finds a CameraSystem. Until it
+// does, the mouse is mapped to the world as if by a camera that hasn't moved.
+var ErrNoCamera = errors.New("CameraSystem not found - have you added the `RenderSystem` before the `MouseSystem`?")
+
 // MouseComponent is the location for the MouseSystem to store its results;
 // to be used / viewed by other Systems
 type MouseComponent struct {
@@ -68,11 +73,60 @@ type MouseComponent struct {
 	startedDragging bool
 	// startedRightDragging is used internally to see if *this* is the object that is being right-dragged
 	rightStartedDragging bool
+	// dragMoved is used internally to see if the mouse moved while *this* was being dragged
+	dragMoved bool
+	// cursor is the CursorComponent given to the entity with AddCursor
+	cursor *CursorComponent
 
 	// IsHUDShader is used to update the mouse component properly for the common.HUDShader
 	IsHUDShader bool
+
+	// Disabled turns off the entity, so it can't be hovered, clicked or
+	// dragged, even if it's tracking. If it was hovered it gets a Leave, and
+	// any drag is dropped. The pointer over it still shows the DisabledCursor
+	// of its CursorComponent.
+	Disabled bool
+	// Hidden skips the entity altogether, like a hidden RenderComponent. It's
+	// for entities that don't have one.
+	Hidden bool
+
+	// Launch turns on pull-to-launch, like a slingshot. Left-dragging the entity
+	// pulls it back, and releasing the mouse launches the body the other way
+	// and sends a LaunchedMessage.
+	Launch bool
+	// MaxPull is the furthest the pull counts, in pixels. Zero is no limit.
+	MaxPull float32
+	// LaunchScale is the impulse in kg m/s for each pixel of pull.
+	LaunchScale float32
+	// DragOrigin is where the mouse was pressed, and PullOffset is how far the
+	// mouse has been pulled from it, up to MaxPull, in world pixels.
+	DragOrigin engo.Point
+	PullOffset engo.Point
+	// LaunchVector is the impulse in kg m/s that the body will be launched with
+	// if the mouse is released now.
+	LaunchVector engo.Point
+
+	// Throw sets the body's velocity to DragVelocity when the mouse is released
+	// after dragging it, so it can be flicked. Clicking it without moving the
+	// mouse leaves its velocity alone.
+	Throw bool
+	// DragVelocity is the velocity of the mouse while the entity is dragged, in
+	// pixels per second.
+	DragVelocity engo.Point
+}
+
+// LaunchedMessage is sent when an entity is launched by releasing a pull.
+type LaunchedMessage struct {
+	Entity *ecs.BasicEntity
+	// Pull is how far it was pulled in pixels, and Impulse is the impulse it
+	// was launched with in kg m/s.
+	Pull    engo.Point
+	Impulse engo.Point
 }
 
+// Type implements the engo.Message interface
+func (LaunchedMessage) Type() string { return "LaunchedMessage" }
+
 type mouseEntity struct {
 	*ecs.BasicEntity
 	*MouseComponent
@@ -81,18 +135,67 @@ type mouseEntity struct {
 	*Box2dComponent
 }
 
+// hidden is whether the entity or its RenderComponent is hidden.
+func (e mouseEntity) hidden() bool {
+	return e.MouseComponent.Hidden || (e.RenderComponent != nil && e.RenderComponent.Hidden)
+}
+
+// HitTestMode is how the MouseSystem finds out what's under the mouse.
+type HitTestMode uint8
+
+const (
+	// HitTestSpace tests against where the SpaceComponent says the entity is,
+	// even if the body is somewhere else.
+	HitTestSpace HitTestMode = iota
+	// HitTestBody tests against where the body actually is, for entities whose
+	// SpaceComponent follows the body.
+	HitTestBody
+)
+
 // MouseSystem listens for mouse events and changes value for MouseComponent accordingly
 type MouseSystem struct {
 	entities []mouseEntity
 	world    *ecs.World
 	camera   *common.CameraSystem
 
+	// HitTest is how entities are hit tested. Neither way moves the bodies.
+	// Defaults to HitTestSpace.
+	HitTest HitTestMode
+
+	// Source is where the pointer comes from. Defaults to EngoPointer, the
+	// mouse.
+	Source  PointerSource
+	pointer Pointer
+
 	mouseX         float32
 	mouseY         float32
 	mouseDown      bool
 	rightMouseDown bool
+
+	// ThrowWindow is how many seconds of mouse movement PointerVelocity is
+	// averaged over. Defaults to 0.1.
+	ThrowWindow float32
+	history     []pointerSample
+
+	// BoxSelect turns on box selection. Left-dragging from where there's no
+	// entity of the MouseSystem selects the bodies in the box.
+	BoxSelect   bool
+	selecting   bool
+	selectStart engo.Point
+	selection   []*box2d.B2Body
+
+	cursor  engo.Cursor
+	hovered *CursorComponent
+}
+
+// pointerSample is where the mouse was in a frame, and how long the frame was.
+type pointerSample struct {
+	x, y, dt float32
 }
 
+// maxPointerSamples caps the history, in case the frames are very short.
+const maxPointerSamples = 64
+
 // Priority implements prioritizer interface
 func (m *MouseSystem) Priority() int { return MouseSystemPriority }
 
@@ -100,65 +203,115 @@ func (m *MouseSystem) Priority() int { return MouseSystemPriority }
 func (m *MouseSystem) New(w *ecs.World) {
 	m.world = w
 
+	// Let a PhysicsSystem added before this one record the pointer
+	for _, system := range m.world.Systems() {
+		switch sys := system.(type) {
+		case *PhysicsSystem:
+			sys.mouse = m
+		}
+	}
+
 	// First check to see if the CameraSystem is available
+	m.findCamera()
+	if m.camera == nil {
+		log.Println("ERROR:", ErrNoCamera)
+	}
+}
+
+// findCamera looks for the CameraSystem in the world. It's called each Update
+// until there is one, in case it's added after the MouseSystem.
+func (m *MouseSystem) findCamera() {
+	if m.world == nil {
+		return
+	}
 	for _, system := range m.world.Systems() {
 		switch sys := system.(type) {
 		case *common.CameraSystem:
 			m.camera = sys
 		}
 	}
+}
 
+// Err is ErrNoCamera if the MouseSystem hasn't found a CameraSystem yet, or
+// nil if it has.
+func (m *MouseSystem) Err() error {
 	if m.camera == nil {
-		log.Println("ERROR: CameraSystem not found - have you added the `RenderSystem` before the `MouseSystem`?")
-		return
+		return ErrNoCamera
 	}
+	return nil
 }
 
-// Add adds a new entity to the MouseSystem
+// view is where the camera is, how far it's zoomed out and its angle. Without
+// a camera it's the middle of the game, not zoomed or turned, so the mouse is
+// in the same place in the world as in the window.
+func (m *MouseSystem) view() (x, y, z, angle float32) {
+	if m.camera == nil {
+		return engo.GameWidth() / 2, engo.GameHeight() / 2, 1, 0
+	}
+	return m.camera.X(), m.camera.Y(), m.camera.Z(), m.camera.Angle()
+}
+
+// Add adds a new entity to the MouseSystem. The render can be nil for entities
+// that aren't drawn.
 func (m *MouseSystem) Add(basic *ecs.BasicEntity, mouse *MouseComponent, space *common.SpaceComponent, render *common.RenderComponent, box *Box2dComponent) {
 	m.entities = append(m.entities, mouseEntity{basic, mouse, space, render, box})
 }
 
-// AddByInterface adds the entity that implements the Mouseable interface to the MouseSystem
-func (m *MouseSystem) AddByInterface(o Mouseable) {
-	m.Add(o.GetBasicEntity(), o.GetMouseComponent(), o.GetSpaceComponent(), o.GetRenderComponent(), o.GetBox2dComponent())
+// AddByInterface adds the entity that implements the Pickable interface to the
+// MouseSystem. If it's Mouseable its RenderComponent is used too.
+func (m *MouseSystem) AddByInterface(o Pickable) {
+	var render *common.RenderComponent
+	if r, ok := o.(common.RenderFace); ok {
+		render = r.GetRenderComponent()
+	}
+	m.Add(o.GetBasicEntity(), o.GetMouseComponent(), o.GetSpaceComponent(), render, o.GetBox2dComponent())
 }
 
-// Remove removes an entity from the MouseSystem
+// Remove removes an entity from the MouseSystem, and takes its body out of the
+// selection.
 func (m *MouseSystem) Remove(basic ecs.BasicEntity) {
-	delete := -1
+	idx := -1
 	for index, entity := range m.entities {
 		if entity.ID() == basic.ID() {
-			delete = index
+			idx = index
 			break
 		}
 	}
-	if delete >= 0 {
-		m.entities = append(m.entities[:delete], m.entities[delete+1:]...)
+	if idx >= 0 {
+		if box := m.entities[idx].Box2dComponent; box != nil {
+			m.deselect(func(b *box2d.B2Body) bool { return b == box.Body })
+		}
+		m.entities = append(m.entities[:idx], m.entities[idx+1:]...)
 	}
 }
 
 // Update updates the MouseComponent based on location of cursor and state of the mouse buttons
 func (m *MouseSystem) Update(dt float32) {
-	// Translate Mouse.X and Mouse.Y into "game coordinates"
-	switch engo.CurrentBackEnd {
-	case engo.BackEndGLFW:
-		m.mouseX = engo.Input.Mouse.X*m.camera.Z() + (m.camera.X()-(engo.GameWidth()/2)*m.camera.Z())/engo.GetGlobalScale().X
-		m.mouseY = engo.Input.Mouse.Y*m.camera.Z() + (m.camera.Y()-(engo.GameHeight()/2)*m.camera.Z())/engo.GetGlobalScale().Y
-	case engo.BackEndMobile:
-		m.mouseX = engo.Input.Mouse.X*m.camera.Z() + (m.camera.X()-(engo.GameWidth()/2)*m.camera.Z()+(engo.ResizeXOffset/2))/engo.GetGlobalScale().X
-		m.mouseY = engo.Input.Mouse.Y*m.camera.Z() + (m.camera.Y()-(engo.GameHeight()/2)*m.camera.Z()+(engo.ResizeYOffset/2))/engo.GetGlobalScale().Y
-	case engo.BackEndWeb:
-		m.mouseX = engo.Input.Mouse.X*m.camera.Z() + (m.camera.X()-(engo.GameWidth()/2)*m.camera.Z()+(engo.ResizeXOffset/2))/engo.GetGlobalScale().X
-		m.mouseY = engo.Input.Mouse.Y*m.camera.Z() + (m.camera.Y()-(engo.GameHeight()/2)*m.camera.Z()+(engo.ResizeYOffset/2))/engo.GetGlobalScale().Y
+	source := m.Source
+	if source == nil {
+		source = EngoPointer{}
 	}
+	m.pointer = source.Pointer()
+
+	if m.camera == nil {
+		m.findCamera()
+	}
+	cx, cy, cz, angle := m.view()
+
+	// Translate the pointer into "game coordinates"
+	m.mouseX = m.pointer.X*cz + (cx-(engo.GameWidth()/2)*cz+m.pointer.Offset.X)/engo.GetGlobalScale().X
+	m.mouseY = m.pointer.Y*cz + (cy-(engo.GameHeight()/2)*cz+m.pointer.Offset.Y)/engo.GetGlobalScale().Y
 
 	// Rotate if needed
-	if m.camera.Angle() != 0 {
-		sin, cos := math.Sincos(m.camera.Angle() * math.Pi / 180)
+	if angle != 0 {
+		sin, cos := math.Sincos(angle * math.Pi / 180)
 		m.mouseX, m.mouseY = m.mouseX*cos+m.mouseY*sin, m.mouseY*cos-m.mouseX*sin
 	}
 
+	m.recordPointer(dt)
+
+	pressedEntity := false
+	var hovered []mouseEntity
 	for _, e := range m.entities {
 		// Reset all values except these
 		*e.MouseComponent = MouseComponent{
@@ -166,7 +319,16 @@ func (m *MouseSystem) Update(dt float32) {
 			Hovered:              e.MouseComponent.Hovered,
 			startedDragging:      e.MouseComponent.startedDragging,
 			rightStartedDragging: e.MouseComponent.rightStartedDragging,
+			dragMoved:            e.MouseComponent.dragMoved,
 			IsHUDShader:          e.MouseComponent.IsHUDShader,
+			Launch:               e.MouseComponent.Launch,
+			MaxPull:              e.MouseComponent.MaxPull,
+			LaunchScale:          e.MouseComponent.LaunchScale,
+			DragOrigin:           e.MouseComponent.DragOrigin,
+			Throw:                e.MouseComponent.Throw,
+			Disabled:             e.MouseComponent.Disabled,
+			Hidden:               e.MouseComponent.Hidden,
+			cursor:               e.MouseComponent.cursor,
 		}
 
 		if e.MouseComponent.Track {
@@ -181,27 +343,27 @@ func (m *MouseSystem) Update(dt float32) {
 			continue
 		}
 
-		//set box2d body to SpaceComponent's position and rotation
-		e.Body.SetTransform(Conv.ToBox2d2Vec(e.Center()), Conv.DegToRad(e.Rotation))
-
-		if e.RenderComponent != nil {
-			// Hardcoded special case for the HUD | TODO: make generic instead of hardcoding
-			if e.MouseComponent.IsHUDShader {
-				mx = engo.Input.Mouse.X
-				my = engo.Input.Mouse.Y
-			}
+		// Hardcoded special case for the HUD | TODO: make generic instead of hardcoding
+		if e.MouseComponent.IsHUDShader {
+			mx = m.pointer.X
+			my = m.pointer.Y
+		}
 
-			if e.RenderComponent.Hidden {
-				continue // skip hidden components
-			}
+		if e.hidden() {
+			continue // skip hidden components
 		}
 
-		mousePoint := box2d.B2Vec2{
-			X: Conv.PxToMeters(mx),
-			Y: Conv.PxToMeters(my),
+		if e.MouseComponent.Disabled {
+			// drop any drags so it doesn't pick them back up when it's enabled
+			e.MouseComponent.startedDragging = false
+			e.MouseComponent.rightStartedDragging = false
+			e.MouseComponent.dragMoved = false
 		}
+
+		mousePoint := m.hitPoint(e, mx, my)
 		var containsMouse bool
 
+		// still hit tested while disabled, for its CursorComponent
 		for f := e.Body.GetFixtureList(); f != nil; f = f.GetNext() {
 			if f.TestPoint(mousePoint) {
 				containsMouse = true
@@ -213,7 +375,7 @@ func (m *MouseSystem) Update(dt float32) {
 		// Check if the X-value is within range
 		// and if the Y-value is within range
 
-		if e.MouseComponent.Track || e.MouseComponent.startedDragging || containsMouse {
+		if !e.MouseComponent.Disabled && (e.MouseComponent.Track || e.MouseComponent.startedDragging || containsMouse) {
 
 			e.MouseComponent.Enter = !e.MouseComponent.Hovered
 			e.MouseComponent.Hovered = true
@@ -225,12 +387,17 @@ func (m *MouseSystem) Update(dt float32) {
 				e.MouseComponent.MouseY = my
 			}
 
-			switch engo.Input.Mouse.Action {
+			switch m.pointer.Action {
 			case engo.Press:
-				switch engo.Input.Mouse.Button {
+				switch m.pointer.Button {
 				case engo.MouseButtonLeft:
 					e.MouseComponent.Clicked = true
+					// tracking entities get every click, but only count if
+					// they're under the mouse
+					pressedEntity = pressedEntity || containsMouse
 					e.MouseComponent.startedDragging = true
+					e.MouseComponent.dragMoved = false
+					e.MouseComponent.DragOrigin = engo.Point{X: mx, Y: my}
 					m.mouseDown = true
 				case engo.MouseButtonRight:
 					e.MouseComponent.RightClicked = true
@@ -238,7 +405,7 @@ func (m *MouseSystem) Update(dt float32) {
 					m.rightMouseDown = true
 				}
 			case engo.Release:
-				switch engo.Input.Mouse.Button {
+				switch m.pointer.Button {
 				case engo.MouseButtonLeft:
 					e.MouseComponent.Released = true
 				case engo.MouseButtonRight:
@@ -247,6 +414,7 @@ func (m *MouseSystem) Update(dt float32) {
 			case engo.Move:
 				if m.mouseDown && e.MouseComponent.startedDragging {
 					e.MouseComponent.Dragged = true
+					e.MouseComponent.dragMoved = true
 				}
 				if m.rightMouseDown && e.MouseComponent.rightStartedDragging {
 					e.MouseComponent.RightDragged = true
@@ -260,11 +428,26 @@ func (m *MouseSystem) Update(dt float32) {
 			e.MouseComponent.Hovered = false
 		}
 
-		if engo.Input.Mouse.Action == engo.Release {
-			switch engo.Input.Mouse.Button {
+		if e.MouseComponent.Launch && e.MouseComponent.startedDragging {
+			m.pull(e, mx, my)
+		}
+		if e.MouseComponent.startedDragging {
+			e.MouseComponent.DragVelocity = m.PointerVelocity()
+		}
+
+		if m.pointer.Action == engo.Release {
+			switch m.pointer.Button {
 			case engo.MouseButtonLeft:
+				if e.MouseComponent.Launch && e.MouseComponent.startedDragging {
+					m.launch(e)
+				}
+				if e.MouseComponent.Throw && e.MouseComponent.startedDragging && e.MouseComponent.dragMoved {
+					e.Body.SetLinearVelocity(Conv.ToBox2d2Vec(e.MouseComponent.DragVelocity))
+					e.Body.SetAwake(true)
+				}
 				e.MouseComponent.Dragged = false
 				e.MouseComponent.startedDragging = false
+				e.MouseComponent.dragMoved = false
 				m.mouseDown = false
 			case engo.MouseButtonRight:
 				e.MouseComponent.RightDragged = false
@@ -273,11 +456,109 @@ func (m *MouseSystem) Update(dt float32) {
 			}
 		}
 
+		if e.MouseComponent.cursor != nil && (containsMouse || e.MouseComponent.startedDragging) {
+			hovered = append(hovered, e)
+		}
+
 		// propagate the modifiers to the mouse component so that game
 		// implementers can take different decisions based on those
-		e.MouseComponent.Modifier = engo.Input.Mouse.Modifer
+		e.MouseComponent.Modifier = m.pointer.Modifier
+	}
+
+	m.updateCursor(hovered)
+
+	if m.BoxSelect {
+		m.updateSelection(pressedEntity)
 	}
 
 	//Remove all bodies on list for removal
 	removeBodies()
+	m.dropDestroyed()
+}
+
+// hitPoint is the mouse in meters, moved so that testing it against the
+// body's fixtures tests it against the entity wherever the HitTest says it is.
+func (m *MouseSystem) hitPoint(e mouseEntity, mx, my float32) box2d.B2Vec2 {
+	p := box2d.B2Vec2{
+		X: Conv.PxToMeters(mx),
+		Y: Conv.PxToMeters(my),
+	}
+	if m.HitTest == HitTestBody {
+		return p
+	}
+	space := box2d.MakeB2TransformByPositionAndRotation(Conv.ToBox2d2Vec(e.Center()), box2d.MakeB2RotFromAngle(Conv.DegToRad(e.Rotation)))
+	return box2d.B2TransformVec2Mul(e.Body.GetTransform(), box2d.B2TransformVec2MulT(space, p))
+}
+
+// hitCenter is where the entity is for hit testing, in world pixels: the center
+// of its SpaceComponent, or the origin of its body for HitTestBody.
+func (m *MouseSystem) hitCenter(e mouseEntity) engo.Point {
+	if m.HitTest == HitTestBody {
+		return Conv.ToEngoPoint(e.Body.GetPosition())
+	}
+	return e.Center()
+}
+
+// pull works out how far the entity has been pulled and what it would be
+// launched with.
+func (m *MouseSystem) pull(e mouseEntity, mx, my float32) {
+	offset := engo.Point{X: mx - e.DragOrigin.X, Y: my - e.DragOrigin.Y}
+	if l := offset.PointDistance(engo.Point{}); e.MaxPull > 0 && l > e.MaxPull {
+		offset.MultiplyScalar(e.MaxPull / l)
+	}
+	e.PullOffset = offset
+	e.LaunchVector = engo.Point{X: -offset.X * e.LaunchScale, Y: -offset.Y * e.LaunchScale}
+}
+
+// launch applies the launch impulse to the body.
+func (m *MouseSystem) launch(e mouseEntity) {
+	impulse := box2d.B2Vec2{X: float64(e.LaunchVector.X), Y: float64(e.LaunchVector.Y)}
+	e.Body.ApplyLinearImpulseToCenter(impulse, true)
+	engo.Mailbox.Dispatch(LaunchedMessage{
+		Entity:  e.BasicEntity,
+		Pull:    e.PullOffset,
+		Impulse: e.LaunchVector,
+	})
+}
+
+// recordPointer adds the mouse's position this frame to the history.
+func (m *MouseSystem) recordPointer(dt float32) {
+	if len(m.history) == maxPointerSamples {
+		m.history = append(m.history[:0], m.history[1:]...)
+	}
+	m.history = append(m.history, pointerSample{m.mouseX, m.mouseY, dt})
+}
+
+// PointerVelocity is the mouse's velocity in pixels per second, averaged over
+// the last ThrowWindow seconds.
+func (m *MouseSystem) PointerVelocity() engo.Point {
+	window := defaultFloat(m.ThrowWindow, 0.1)
+	if len(m.history) < 2 {
+		return engo.Point{}
+	}
+	last := m.history[len(m.history)-1]
+	var elapsed float32
+	first := last
+	for i := len(m.history) - 1; i > 0 && elapsed < window; i-- {
+		elapsed += m.history[i].dt
+		first = m.history[i-1]
+	}
+	if elapsed <= 0 {
+		return engo.Point{}
+	}
+	return engo.Point{X: (last.x - first.x) / elapsed, Y: (last.y - first.y) / elapsed}
+}
+
+// toWindow turns a point in the world into window coordinates, the opposite of
+// what Update does to the pointer. The pointer's Offset is left out.
+func (m *MouseSystem) toWindow(p engo.Point) engo.Point {
+	cx, cy, cz, angle := m.view()
+	if angle != 0 {
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		p.X, p.Y = p.X*cos-p.Y*sin, p.Y*cos+p.X*sin
+	}
+	return engo.Point{
+		X: (p.X - (cx-(engo.GameWidth()/2)*cz)/engo.GetGlobalScale().X) / cz,
+		Y: (p.Y - (cy-(engo.GameHeight()/2)*cz)/engo.GetGlobalScale().Y) / cz,
+	}
 }
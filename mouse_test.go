@@ -136,6 +136,51 @@ func TestMouseSystemNewNoCamera(t *testing.T) {
 	}
 }
 
+// without a camera the mouse maps straight to the world, and the camera is
+// picked up if it's added later
+func TestMouseSystemLateCamera(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{1})
+	e := sys.entities[0]
+
+	var str bytes.Buffer
+	log.SetOutput(&str)
+
+	w := ecs.World{}
+	m := &MouseSystem{}
+	w.AddSystem(m)
+	if m.Err() != ErrNoCamera {
+		t.Errorf("Err should be ErrNoCamera without a camera, got: %v", m.Err())
+	}
+	m.Add(e.BasicEntity, e.MouseComponent, e.SpaceComponent, nil, e.Box2dComponent)
+
+	script := &ScriptedPointer{}
+	script.Push(Pointer{X: 5, Y: 5, Action: engo.Move}, Pointer{X: 50, Y: 50, Action: engo.Move})
+	m.Source = script
+	m.Update(updateTime)
+	if !e.Hovered || e.MouseX != 5 || e.MouseY != 5 {
+		t.Errorf("Mouse should map straight to the world without a camera, got: (%v, %v)", e.MouseX, e.MouseY)
+	}
+	if p := m.toWindow(engo.Point{X: 5, Y: 5}); p.X != 5 || p.Y != 5 {
+		t.Errorf("World should map straight to the window without a camera, got: %v", p)
+	}
+
+	camera := &common.CameraSystem{}
+	w.AddSystem(camera)
+	m.Update(updateTime)
+	if m.camera != camera || m.Err() != nil {
+		t.Errorf("Camera added after the MouseSystem should be picked up")
+	}
+	if e.Hovered {
+		t.Errorf("Mouse should have moved off the entity")
+	}
+}
+
 // Test hovering
 func TestMouseSystemUpdateHoverint(t *testing.T) {
 	updateTime := float32(1.0 / 60.0)
@@ -706,3 +751,272 @@ func TestMouseSystemAddByInterface(t *testing.T) {
 		t.Errorf("AddByInterface for MouseSystem failed; wanted %d, have %d entities", 1, len(sys.entities))
 	}
 }
+
+func TestMouseSystemLaunch(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{1})
+
+	var launched []LaunchedMessage
+	engo.Mailbox.Listen("LaunchedMessage", func(msg engo.Message) {
+		launched = append(launched, msg.(LaunchedMessage))
+	})
+
+	e := sys.entities[0]
+	e.Launch = true
+	e.MaxPull = 20
+	e.LaunchScale = 0.1
+	e.Body.SetLinearVelocity(box2d.B2Vec2{})
+
+	//Press on the entity
+	engo.Input.Mouse.X = 5
+	engo.Input.Mouse.Y = 5
+	engo.Input.Mouse.Button = engo.MouseButtonLeft
+	engo.Input.Mouse.Action = engo.Press
+	sys.Update(updateTime)
+
+	if e.DragOrigin != (engo.Point{X: 5, Y: 5}) {
+		t.Errorf("Drag origin was not set to where the mouse was pressed, got: %v", e.DragOrigin)
+	}
+
+	//Pull back a little
+	engo.Input.Mouse.X = 15
+	engo.Input.Mouse.Action = engo.Move
+	sys.Update(updateTime)
+
+	if e.PullOffset != (engo.Point{X: 10, Y: 0}) || e.LaunchVector != (engo.Point{X: -1, Y: 0}) {
+		t.Errorf("Pull was not tracked, offset: %v, launch: %v", e.PullOffset, e.LaunchVector)
+	}
+
+	//Pull back past the max
+	engo.Input.Mouse.X = 35
+	sys.Update(updateTime)
+
+	if e.PullOffset != (engo.Point{X: 20, Y: 0}) || e.LaunchVector != (engo.Point{X: -2, Y: 0}) {
+		t.Errorf("Pull was not clamped to the max, offset: %v, launch: %v", e.PullOffset, e.LaunchVector)
+	}
+	if len(launched) != 0 {
+		t.Errorf("Entity was launched before it was released")
+	}
+
+	//Release
+	engo.Input.Mouse.Action = engo.Release
+	sys.Update(updateTime)
+
+	if len(launched) != 1 || launched[0].Entity.ID() != basics[0].ID() || launched[0].Impulse != (engo.Point{X: -2, Y: 0}) {
+		t.Fatalf("Launched message was not sent on release, got: %v", launched)
+	}
+	// 2 kg m/s on a quarter kg body
+	if v := e.Body.GetLinearVelocity(); v != (box2d.B2Vec2{X: -8, Y: 0}) {
+		t.Errorf("Body was not launched, velocity: %v", v)
+	}
+
+	//Releasing again doesn't launch
+	sys.Update(updateTime)
+	if len(launched) != 1 {
+		t.Errorf("Entity was launched again without being pulled")
+	}
+}
+
+func TestMouseSystemThrow(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{1})
+
+	sys.ThrowWindow = 0.09
+	e := sys.entities[0]
+	e.Throw = true
+	e.Body.SetLinearVelocity(box2d.B2Vec2{})
+
+	//Press on the entity
+	engo.Input.Mouse.X = 5
+	engo.Input.Mouse.Y = 5
+	engo.Input.Mouse.Button = engo.MouseButtonLeft
+	engo.Input.Mouse.Action = engo.Press
+	sys.Update(updateTime)
+
+	//Drag it 2 pixels a frame
+	engo.Input.Mouse.Action = engo.Move
+	for i := 0; i < 10; i++ {
+		engo.Input.Mouse.X += 2
+		sys.Update(updateTime)
+	}
+
+	if v := e.DragVelocity; math.Abs(v.X-120) > 0.01 || v.Y != 0 {
+		t.Errorf("Drag velocity should be 120 pixels per second, got: %v", v)
+	}
+	if v := sys.PointerVelocity(); v != e.DragVelocity {
+		t.Errorf("Pointer velocity should match the drag velocity, got: %v", v)
+	}
+
+	//Release without moving, so the last frame is still
+	engo.Input.Mouse.Action = engo.Release
+	sys.Update(updateTime)
+
+	if v := e.Body.GetLinearVelocity(); math.Abs(float32(v.X)-5) > 0.001 || v.Y != 0 {
+		t.Errorf("Body was not thrown at 100 pixels per second, velocity: %v", v)
+	}
+
+	//Not being dragged, so it isn't thrown again
+	e.Body.SetLinearVelocity(box2d.B2Vec2{})
+	sys.Update(updateTime)
+	if v := e.Body.GetLinearVelocity(); v != (box2d.B2Vec2{}) {
+		t.Errorf("Body was thrown without being dragged, velocity: %v", v)
+	}
+
+	//Clicking a moving body without dragging it doesn't stop it, even with the
+	//mouse still moving from before
+	engo.Input.Mouse.X = 5
+	engo.Input.Mouse.Action = engo.Move
+	sys.Update(updateTime)
+	moving := box2d.B2Vec2{X: 3, Y: -2}
+	e.Body.SetLinearVelocity(moving)
+	engo.Input.Mouse.Action = engo.Press
+	sys.Update(updateTime)
+	engo.Input.Mouse.Action = engo.Release
+	sys.Update(updateTime)
+	if v := e.Body.GetLinearVelocity(); v != moving {
+		t.Errorf("Clicking the body should not change its velocity, want: %v, got: %v", moving, v)
+	}
+}
+
+type pickableEntity struct {
+	*ecs.BasicEntity
+	*MouseComponent
+	*common.SpaceComponent
+	*Box2dComponent
+}
+
+func TestMouseSystemPickable(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{0})
+
+	basic := ecs.NewBasic()
+	entity := pickableEntity{&basic, &MouseComponent{}, &common.SpaceComponent{Width: 10, Height: 10}, &Box2dComponent{}}
+	bodyDef := box2d.NewB2BodyDef()
+	bodyDef.Position = Conv.ToBox2d2Vec(entity.SpaceComponent.Center())
+	entity.Body = World.CreateBody(bodyDef)
+	var shape box2d.B2PolygonShape
+	shape.SetAsBox(Conv.PxToMeters(5), Conv.PxToMeters(5))
+	entity.Body.CreateFixture(&shape, 1)
+	sys.AddByInterface(entity)
+	if len(sys.entities) != 1 || sys.entities[0].RenderComponent != nil {
+		t.Fatalf("AddByInterface should add entities without a RenderComponent")
+	}
+
+	script := &ScriptedPointer{}
+	sys.Source = script
+	script.Push(
+		Pointer{X: 5, Y: 5, Action: engo.Press, Button: engo.MouseButtonLeft},
+		Pointer{X: 6, Y: 6, Action: engo.Move, Button: engo.MouseButtonLeft},
+	)
+	sys.Update(updateTime)
+	if !entity.Clicked || !entity.Hovered {
+		t.Errorf("Entity without a RenderComponent should be clicked")
+	}
+	sys.Update(updateTime)
+	if !entity.Dragged {
+		t.Errorf("Entity without a RenderComponent should be dragged")
+	}
+
+	// disabling drops the drag and it leaves
+	entity.Disabled = true
+	script.Push(Pointer{X: 7, Y: 7, Action: engo.Move, Button: engo.MouseButtonLeft})
+	sys.Update(updateTime)
+	if entity.Hovered || entity.Dragged || !entity.Leave || !entity.Disabled {
+		t.Errorf("Disabled entity should not be hovered or dragged, got: %+v", *entity.MouseComponent)
+	}
+	script.Push(Pointer{X: 7, Y: 7, Action: engo.Press, Button: engo.MouseButtonLeft})
+	sys.Update(updateTime)
+	if entity.Clicked {
+		t.Errorf("Disabled entity should not be clicked")
+	}
+	entity.Track = true
+	sys.Update(updateTime)
+	if entity.Hovered {
+		t.Errorf("Disabled entity should not be hovered even when tracking")
+	}
+
+	entity.Disabled = false
+	entity.Track = false
+	script.Push(Pointer{X: 7, Y: 7, Action: engo.Release, Button: engo.MouseButtonLeft})
+	sys.Update(updateTime)
+	if !entity.Hovered || !entity.Released {
+		t.Errorf("Entity should be hovered again once it's enabled")
+	}
+
+	entity.Hidden = true
+	script.Push(Pointer{X: 7, Y: 7, Action: engo.Press, Button: engo.MouseButtonLeft})
+	sys.Update(updateTime)
+	if entity.Clicked || !entity.Hidden {
+		t.Errorf("Hidden entity should not be clicked")
+	}
+}
+
+func TestMouseSystemHitTest(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{1})
+
+	e := sys.entities[0]
+	// the body is over at (55, 5) and asleep, the space is a long thin box
+	// turned on its side at (5, 5)
+	e.Body.DestroyFixture(e.Body.GetFixtureList())
+	var shape box2d.B2PolygonShape
+	shape.SetAsBox(Conv.PxToMeters(20), Conv.PxToMeters(2))
+	e.Body.CreateFixture(&shape, 1)
+	e.Body.SetTransform(Conv.ToBox2d2Vec(engo.Point{X: 55, Y: 5}), 0)
+	e.Body.SetAwake(false)
+	e.SpaceComponent.Rotation = 90
+	e.SpaceComponent.Position.X = 10 // so the center stays put
+
+	hit := func(x, y float32) bool {
+		script := &ScriptedPointer{}
+		script.Push(Pointer{X: x, Y: y, Action: engo.Move})
+		sys.Source = script
+		sys.Update(updateTime)
+		return e.Hovered
+	}
+
+	tests := []struct {
+		mode HitTestMode
+		x, y float32
+		want bool
+	}{
+		{HitTestSpace, 5, 20, true},
+		{HitTestSpace, 20, 5, false},
+		{HitTestSpace, 70, 5, false},
+		{HitTestBody, 70, 5, true},
+		{HitTestBody, 5, 20, false},
+	}
+	for _, test := range tests {
+		sys.HitTest = test.mode
+		if got := hit(test.x, test.y); got != test.want {
+			t.Errorf("Hit test mode %v at (%v, %v), want: %v, got: %v", test.mode, test.x, test.y, test.want, got)
+		}
+	}
+
+	if p := Conv.ToEngoPoint(e.Body.GetPosition()); p.X < 54.99 || p.X > 55.01 || e.Body.GetAngle() != 0 {
+		t.Errorf("Hit testing should not move the body, got: %v at %v", p, e.Body.GetAngle())
+	}
+	if e.Body.IsAwake() {
+		t.Errorf("Hit testing should not wake the body")
+	}
+}
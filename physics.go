@@ -1,7 +1,11 @@
 package engoBox2dSystem
 
 import (
+	"sync"
+	"time"
+
 	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/common"
 )
 
@@ -14,9 +18,55 @@ type physicsEntity struct {
 // PhysicsSystem provides a system that allows entites to follow the box2d
 // physics engine calculations.
 type PhysicsSystem struct {
-	entities []physicsEntity
+	entities  []physicsEntity
+	fluids    []fluidEntity
+	effectors []effectorEntity
 
 	VelocityIterations, PositionIterations int
+
+	// RollbackFrames is how many Updates can be undone with Rewind. A snapshot
+	// of the World is kept for each of them, so leave it at zero if you don't
+	// need rollback.
+	RollbackFrames int
+
+	// HashSteps sends out a StepHashMessage after each step of the World, which
+	// can be compared with CompareStepHashes to find where two Worlds desynced.
+	HashSteps bool
+
+	// Recorder, if set, records each Update so it can be played back later
+	// with a Player.
+	Recorder *Recorder
+
+	// Profile times the syncing and stepping of each Update and sends out a
+	// PhysicsStatsMessage with the timings and counts of the World.
+	Profile bool
+	// StatsWindow is how many of the last Updates StatsSummary covers.
+	// Defaults to 120.
+	StatsWindow int
+
+	player *Player
+	mouse  *MouseSystem
+
+	// statsMu guards the stats, which StatsSummary can read from other
+	// goroutines
+	statsMu   sync.Mutex
+	stats     physicsStatsWindow
+	lastStats PhysicsStatsMessage
+
+	steps        uint64
+	history      rollbackHistory
+	replayFrames int
+}
+
+// New hooks the PhysicsSystem up to the World's MouseSystem, so the Recorder
+// can record its pointer.
+func (b *PhysicsSystem) New(w *ecs.World) {
+	for _, system := range w.Systems() {
+		switch sys := system.(type) {
+		case *MouseSystem:
+			b.mouse = sys
+		}
+	}
 }
 
 // Add adds the entity to the physics system
@@ -30,29 +80,86 @@ func (b *PhysicsSystem) AddByInterface(o Physicsable) {
 	b.Add(o.GetBasicEntity(), o.GetSpaceComponent(), o.GetBox2dComponent())
 }
 
-// Remove removes the entity from the physics system.
+// Remove removes the entity, fluid volume, or effector from the physics system.
 func (b *PhysicsSystem) Remove(basic ecs.BasicEntity) {
-	delete := -1
+	idx := -1
 	for index, e := range b.entities {
 		if e.BasicEntity.ID() == basic.ID() {
-			delete = index
+			idx = index
+			break
+		}
+	}
+	if idx >= 0 {
+		b.entities = append(b.entities[:idx], b.entities[idx+1:]...)
+	}
+	idx = -1
+	for index, f := range b.fluids {
+		if f.BasicEntity.ID() == basic.ID() {
+			idx = index
+			break
+		}
+	}
+	if idx >= 0 {
+		b.fluids = append(b.fluids[:idx], b.fluids[idx+1:]...)
+	}
+	idx = -1
+	for index, e := range b.effectors {
+		if e.BasicEntity.ID() == basic.ID() {
+			idx = index
 			break
 		}
 	}
-	if delete >= 0 {
-		b.entities = append(b.entities[:delete], b.entities[delete+1:]...)
+	if idx >= 0 {
+		b.effectors = append(b.effectors[:idx], b.effectors[idx+1:]...)
 	}
 }
 
 // Update runs every time the systems update. Updates the box2d world and simulates
 // physics based on the timestep, positions, and forces on the bodies.
 func (b *PhysicsSystem) Update(dt float32) {
+	if b.RollbackFrames > 0 && b.history.count == 0 {
+		b.record()
+	}
+	if b.replayFrames > 0 {
+		replaying = true
+		defer func() { replaying = false }()
+		b.replayFrames--
+	}
+
+	if b.player != nil {
+		b.player.apply(b)
+	}
+	if b.Recorder != nil {
+		b.Recorder.capture(b, dt)
+	}
+
+	var start, synced, stepped, hashed time.Time
+	if b.Profile {
+		start = time.Now()
+	}
+
 	//Set World components to the Render/Space Components
 	for _, e := range b.entities {
 		e.Body.SetTransform(Conv.ToBox2d2Vec(e.Center()), Conv.DegToRad(e.Rotation))
 	}
+	b.applyFluids()
+	b.applyEffectors()
 
+	if b.Profile {
+		synced = time.Now()
+	}
 	World.Step(float64(dt), b.VelocityIterations, b.PositionIterations)
+	if b.Profile {
+		stepped = time.Now()
+	}
+	if b.HashSteps {
+		hash, bodies := HashWorld()
+		engo.Mailbox.Dispatch(StepHashMessage{Step: b.steps, Hash: hash, Bodies: bodies})
+	}
+	b.steps++
+	if b.Profile {
+		hashed = time.Now()
+	}
 
 	//Update Render/Space components to World components after simulation
 	for _, e := range b.entities {
@@ -60,5 +167,13 @@ func (b *PhysicsSystem) Update(dt float32) {
 		e.SpaceComponent.SetCenter(Conv.ToEngoPoint(e.Body.GetPosition()))
 	}
 
+	if b.Profile {
+		b.profile(synced.Sub(start), stepped.Sub(synced), time.Since(hashed))
+	}
+
 	removeBodies()
+	if b.Recorder != nil {
+		b.Recorder.stepped(b)
+	}
+	b.record()
 }
@@ -0,0 +1,71 @@
+package engoBox2dSystem
+
+import "github.com/EngoEngine/engo"
+
+// PointerSource is where the MouseSystem gets the pointer each frame, so it
+// can be driven by something other than the mouse, like a gamepad or an AI.
+type PointerSource interface {
+	// Pointer returns the pointer for this frame. It's called once per Update.
+	Pointer() Pointer
+}
+
+// Pointer is the state of a pointer for a frame. The position is in window
+// coordinates, like engo.Input.Mouse.
+type Pointer struct {
+	X, Y     float32
+	Action   engo.Action
+	Button   engo.MouseButton
+	Modifier engo.Modifier
+	// Offset of the game in the window, in pixels, before the global scale.
+	// The mobile and web back ends have one when the window is resized.
+	Offset engo.Point
+}
+
+// EngoPointer reads the pointer from engo.Input.Mouse. It's the MouseSystem's
+// default source.
+type EngoPointer struct{}
+
+// Pointer implements the PointerSource interface
+func (EngoPointer) Pointer() Pointer {
+	p := Pointer{
+		X:        engo.Input.Mouse.X,
+		Y:        engo.Input.Mouse.Y,
+		Action:   engo.Input.Mouse.Action,
+		Button:   engo.Input.Mouse.Button,
+		Modifier: engo.Input.Mouse.Modifer,
+	}
+	switch engo.CurrentBackEnd {
+	case engo.BackEndMobile, engo.BackEndWeb:
+		p.Offset = engo.Point{X: engo.ResizeXOffset / 2, Y: engo.ResizeYOffset / 2}
+	}
+	return p
+}
+
+// ScriptedPointer plays back a list of pointers, one a frame, for tests and
+// demos. Once it runs out, the pointer stays where it was with no action.
+type ScriptedPointer struct {
+	Frames []Pointer
+
+	last Pointer
+}
+
+// Push adds frames to the end of the script.
+func (s *ScriptedPointer) Push(frames ...Pointer) {
+	s.Frames = append(s.Frames, frames...)
+}
+
+// Done checks if all the frames have been played.
+func (s *ScriptedPointer) Done() bool {
+	return len(s.Frames) == 0
+}
+
+// Pointer implements the PointerSource interface
+func (s *ScriptedPointer) Pointer() Pointer {
+	if len(s.Frames) == 0 {
+		s.last.Action = engo.Neutral
+		return s.last
+	}
+	s.last = s.Frames[0]
+	s.Frames = s.Frames[1:]
+	return s.last
+}
@@ -0,0 +1,98 @@
+package engoBox2dSystem
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+)
+
+func TestScriptedPointer(t *testing.T) {
+	s := &ScriptedPointer{}
+	s.Push(
+		Pointer{X: 1, Y: 2, Action: engo.Press, Button: engo.MouseButtonRight},
+		Pointer{X: 3, Y: 4, Action: engo.Move, Button: engo.MouseButtonRight, Modifier: engo.Shift},
+	)
+	if s.Done() {
+		t.Fatalf("script should not be done before it's played")
+	}
+	if p := s.Pointer(); p.X != 1 || p.Y != 2 || p.Action != engo.Press || p.Button != engo.MouseButtonRight {
+		t.Errorf("first frame is wrong, got: %+v", p)
+	}
+	if p := s.Pointer(); p.X != 3 || p.Modifier != engo.Shift {
+		t.Errorf("second frame is wrong, got: %+v", p)
+	}
+	if !s.Done() {
+		t.Errorf("script should be done after all frames are played")
+	}
+	if p := s.Pointer(); p.X != 3 || p.Y != 4 || p.Action != engo.Neutral || p.Modifier != engo.Shift {
+		t.Errorf("pointer should stay put with no action after the script, got: %+v", p)
+	}
+}
+
+func TestEngoPointer(t *testing.T) {
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{0})
+
+	engo.Input.Mouse.X = 7
+	engo.Input.Mouse.Y = 8
+	engo.Input.Mouse.Action = engo.Release
+	engo.Input.Mouse.Button = engo.MouseButtonMiddle
+	engo.Input.Mouse.Modifer = engo.Control
+	defer func() { engo.Input.Mouse.Modifer = 0 }()
+
+	want := Pointer{X: 7, Y: 8, Action: engo.Release, Button: engo.MouseButtonMiddle, Modifier: engo.Control}
+	if p := (EngoPointer{}).Pointer(); p != want {
+		t.Errorf("pointer should match engo's mouse, want: %+v, got: %+v", want, p)
+	}
+}
+
+func TestMouseSystemPointerSource(t *testing.T) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{1})
+
+	// the engo mouse is nowhere near the entity
+	engo.Input.Mouse.X = 90
+	engo.Input.Mouse.Y = 90
+	engo.Input.Mouse.Action = engo.Neutral
+
+	script := &ScriptedPointer{}
+	script.Push(
+		Pointer{X: 5, Y: 5, Action: engo.Press, Button: engo.MouseButtonLeft, Modifier: engo.Shift},
+		Pointer{X: 8, Y: 8, Action: engo.Move, Button: engo.MouseButtonLeft},
+		Pointer{X: 8, Y: 8, Action: engo.Release, Button: engo.MouseButtonLeft},
+	)
+	sys.Source = script
+	e := sys.entities[0]
+
+	sys.Update(updateTime)
+	if !e.Clicked || !e.Hovered || e.Modifier != engo.Shift {
+		t.Errorf("Entity was not clicked by the scripted pointer")
+	}
+	sys.Update(updateTime)
+	if !e.Dragged {
+		t.Errorf("Entity was not dragged by the scripted pointer")
+	}
+	sys.Update(updateTime)
+	if !e.Released || e.Dragged {
+		t.Errorf("Entity was not released by the scripted pointer")
+	}
+	sys.Update(updateTime)
+	if !e.Hovered || e.Released {
+		t.Errorf("Pointer should stay over the entity after the script ends")
+	}
+
+	sys.Source = nil
+	sys.Update(updateTime)
+	if e.Hovered || !e.Leave {
+		t.Errorf("Mouse system should go back to the engo mouse without a source")
+	}
+}
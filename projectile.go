@@ -0,0 +1,266 @@
+package engoBox2dSystem
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// ProjectileComponent makes a body a projectile, like a bullet or an arrow.
+// Projectiles use continuous collision so they don't pass through thin walls,
+// and send a ProjectileHitMessage for each body they hit.
+type ProjectileComponent struct {
+	// Lifetime in seconds before the projectile expires. Zero is forever.
+	Lifetime float32
+	// MaxRange in pixels the projectile can travel before it expires. Zero is
+	// no limit.
+	MaxRange float32
+	// Pierce is how many bodies the projectile passes through. It expires when
+	// it hits one more.
+	Pierce int
+
+	// Damage and Data are passed along in the ProjectileHitMessage.
+	Damage float64
+	Data   interface{}
+
+	// Pool, if set, gets the projectile's body back when it expires.
+	Pool *ProjectilePool
+
+	// Age is how long the projectile has been flying, in seconds.
+	Age float32
+	// Hits is how many bodies the projectile has hit.
+	Hits int
+
+	start  box2d.B2Vec2
+	hit    map[*box2d.B2Body]bool
+	done   bool
+	entity *ecs.BasicEntity
+}
+
+// ProjectileHitMessage is sent when a projectile hits a body. It's sent during
+// the step of the World, so bodies can't be created or destroyed until after.
+type ProjectileHitMessage struct {
+	Projectile *ecs.BasicEntity
+	Component  *ProjectileComponent
+	// Other is the body that was hit. OtherID is the ID of its entity, if it
+	// was added to the CollisionSystem.
+	Other      *box2d.B2Body
+	OtherID    uint64
+	HasOtherID bool
+	// Point is where the projectile hit, and Normal points from the projectile
+	// into the body it hit.
+	Point  engo.Point
+	Normal engo.Point
+	Damage float64
+	Data   interface{}
+}
+
+// Type implements the engo.Message interface
+func (ProjectileHitMessage) Type() string { return "ProjectileHitMessage" }
+
+// ExpireReason is why a projectile expired.
+type ExpireReason uint8
+
+const (
+	// ExpiredHit is when the projectile hit more bodies than it can pierce.
+	ExpiredHit ExpireReason = iota
+	// ExpiredLifetime is when the projectile outlived its Lifetime.
+	ExpiredLifetime
+	// ExpiredRange is when the projectile went past its MaxRange.
+	ExpiredRange
+)
+
+// ProjectileExpiredMessage is sent when a projectile expires. It's been removed
+// from the ProjectileSystem and its body put back in its pool, if it has one,
+// so the entity should be removed from the ecs.World.
+type ProjectileExpiredMessage struct {
+	Projectile *ecs.BasicEntity
+	Reason     ExpireReason
+}
+
+// Type implements the engo.Message interface
+func (ProjectileExpiredMessage) Type() string { return "ProjectileExpiredMessage" }
+
+// ProjectilePool keeps the bodies of expired projectiles to be used again,
+// rather than creating and destroying a body each shot. Each pool makes one
+// kind of projectile.
+type ProjectilePool struct {
+	// Shape, Density and Filter are used for the fixture of new bodies.
+	Shape   box2d.B2ShapeInterface
+	Density float64
+	Filter  box2d.B2Filter
+
+	// Created is how many bodies the pool has created.
+	Created int
+
+	free []*box2d.B2Body
+}
+
+// Get returns a projectile body at the position with the velocity, in pixels
+// per second. Don't destroy the body; give it back with Put instead.
+func (p *ProjectilePool) Get(position, velocity engo.Point) *box2d.B2Body {
+	var body *box2d.B2Body
+	if n := len(p.free); n > 0 {
+		body = p.free[n-1]
+		p.free = p.free[:n-1]
+		body.SetTransform(Conv.ToBox2d2Vec(position), 0)
+		body.SetAngularVelocity(0)
+		body.SetActive(true)
+		body.SetAwake(true)
+	} else {
+		def := box2d.NewB2BodyDef()
+		def.Type = box2d.B2BodyType.B2_dynamicBody
+		def.Position = Conv.ToBox2d2Vec(position)
+		def.Bullet = true
+		body = World.CreateBody(def)
+		fd := box2d.MakeB2FixtureDef()
+		fd.Shape = p.Shape
+		fd.Density = p.Density
+		fd.Filter = p.Filter
+		body.CreateFixtureFromDef(&fd)
+		p.Created++
+	}
+	body.SetLinearVelocity(Conv.ToBox2d2Vec(velocity))
+	return body
+}
+
+// Put gives a body back to the pool. It stays in the World, but inactive.
+func (p *ProjectilePool) Put(body *box2d.B2Body) {
+	body.SetActive(false)
+	body.SetUserData(nil)
+	p.free = append(p.free, body)
+}
+
+type projectileEntity struct {
+	*ecs.BasicEntity
+	*ProjectileComponent
+	*Box2dComponent
+}
+
+// ProjectileSystem tracks projectiles and sends out their hits. It needs the
+// CollisionSystem to be in the ecs.World too, since the hits are found in its
+// PreSolve callback.
+type ProjectileSystem struct {
+	entities []projectileEntity
+	byBody   map[*box2d.B2Body]*ProjectileComponent
+}
+
+// New listens for the CollisionSystem's PreSolveMessages.
+func (p *ProjectileSystem) New(w *ecs.World) {
+	engo.Mailbox.Listen("PreSolveMessage", func(msg engo.Message) {
+		if m, ok := msg.(PreSolveMessage); ok {
+			p.preSolve(m.Contact)
+		}
+	})
+}
+
+// Add adds a projectile to the system and makes its body a bullet.
+func (p *ProjectileSystem) Add(basic *ecs.BasicEntity, projectile *ProjectileComponent, box *Box2dComponent) {
+	if p.byBody == nil {
+		p.byBody = make(map[*box2d.B2Body]*ProjectileComponent)
+	}
+	box.Body.SetBullet(true)
+	projectile.start = box.Body.GetPosition()
+	projectile.hit = make(map[*box2d.B2Body]bool)
+	projectile.done = false
+	projectile.entity = basic
+	p.byBody[box.Body] = projectile
+	p.entities = append(p.entities, projectileEntity{basic, projectile, box})
+}
+
+// Remove removes the projectile from the system.
+func (p *ProjectileSystem) Remove(basic ecs.BasicEntity) {
+	idx := -1
+	for index, e := range p.entities {
+		if e.BasicEntity.ID() == basic.ID() {
+			idx = index
+			break
+		}
+	}
+	if idx >= 0 {
+		p.forget(p.entities[idx])
+		p.entities = append(p.entities[:idx], p.entities[idx+1:]...)
+	}
+}
+
+// forget stops looking for the projectile's hits.
+func (p *ProjectileSystem) forget(e projectileEntity) {
+	if p.byBody[e.Body] == e.ProjectileComponent {
+		delete(p.byBody, e.Body)
+	}
+}
+
+// Update ages the projectiles and expires them.
+func (p *ProjectileSystem) Update(dt float32) {
+	var expired []ProjectileExpiredMessage
+	kept := p.entities[:0]
+	for _, e := range p.entities {
+		e.Age += dt
+		reason, expire := ExpiredHit, e.done
+		switch {
+		case expire:
+		case e.Lifetime > 0 && e.Age >= e.Lifetime:
+			reason, expire = ExpiredLifetime, true
+		case e.MaxRange > 0 && Conv.MetersToPx(box2d.B2Vec2Sub(e.Body.GetPosition(), e.start).Length()) >= e.MaxRange:
+			reason, expire = ExpiredRange, true
+		}
+		if !expire {
+			kept = append(kept, e)
+			continue
+		}
+		p.forget(e)
+		if e.Pool != nil {
+			e.Pool.Put(e.Body)
+		}
+		expired = append(expired, ProjectileExpiredMessage{Projectile: e.BasicEntity, Reason: reason})
+	}
+	for i := len(kept); i < len(p.entities); i++ {
+		p.entities[i] = projectileEntity{}
+	}
+	p.entities = kept
+	for _, m := range expired {
+		engo.Mailbox.Dispatch(m)
+	}
+}
+
+// preSolve turns off the contacts of projectiles, so they pass through what
+// they hit, and sends a ProjectileHitMessage the first time they touch a body.
+func (p *ProjectileSystem) preSolve(contact box2d.B2ContactInterface) {
+	fixture, other := contact.GetFixtureA(), contact.GetFixtureB()
+	projectile, ok := p.byBody[fixture.GetBody()]
+	if !ok {
+		fixture, other = other, fixture
+		if projectile, ok = p.byBody[fixture.GetBody()]; !ok {
+			return
+		}
+	}
+	contact.SetEnabled(false)
+	body := other.GetBody()
+	if projectile.done || projectile.hit[body] {
+		return
+	}
+	projectile.hit[body] = true
+	projectile.Hits++
+	if projectile.Hits > projectile.Pierce {
+		projectile.done = true
+	}
+
+	var wm box2d.B2WorldManifold
+	contact.GetWorldManifold(&wm)
+	normal := wm.Normal
+	if fixture != contact.GetFixtureA() {
+		normal = box2d.B2Vec2MulScalar(-1, normal)
+	}
+	msg := ProjectileHitMessage{
+		Projectile: projectile.entity,
+		Component:  projectile,
+		Other:      body,
+		Point:      Conv.ToEngoPoint(wm.Points[0]),
+		Normal:     engo.Point{X: float32(normal.X), Y: float32(normal.Y)},
+		Damage:     projectile.Damage,
+		Data:       projectile.Data,
+	}
+	msg.OtherID, msg.HasOtherID = body.GetUserData().(uint64)
+	engo.Mailbox.Dispatch(msg)
+}
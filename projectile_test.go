@@ -0,0 +1,179 @@
+package engoBox2dSystem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+func TestProjectileHit(t *testing.T) {
+	newTestWorld(t, 0)
+	newTestMailbox(t)
+	(&CollisionSystem{}).New(nil)
+	sys := &ProjectileSystem{}
+	sys.New(nil)
+	var hits []ProjectileHitMessage
+	var expired []ProjectileExpiredMessage
+	engo.Mailbox.Listen("ProjectileHitMessage", func(msg engo.Message) {
+		hits = append(hits, msg.(ProjectileHitMessage))
+	})
+	engo.Mailbox.Listen("ProjectileExpiredMessage", func(msg engo.Message) {
+		expired = append(expired, msg.(ProjectileExpiredMessage))
+	})
+
+	shape := box2d.NewB2CircleShape()
+	shape.M_radius = 0.05
+	pool := &ProjectilePool{Shape: shape, Density: 1}
+	// shoots right at 2400 pixels per second, 40 pixels a frame, which is as
+	// fast as box2d lets bodies go
+	fire := func(y float32, projectile *ProjectileComponent) *box2d.B2Body {
+		projectile.Pool = pool
+		body := pool.Get(engo.Point{X: 0, Y: y}, engo.Point{X: 2400, Y: 0})
+		basic := ecs.NewBasic()
+		sys.Add(&basic, projectile, &Box2dComponent{Body: body})
+		return body
+	}
+
+	// a wall a tenth as thick as a frame of travel
+	wall := addStatic(engo.Point{X: 150, Y: 0}, 4, 100, 0)
+	wall.SetUserData(uint64(7))
+
+	projectile := &ProjectileComponent{Damage: 5, Data: "bullet"}
+	body := fire(0, projectile)
+	stepFrames(5, stepWorld, sys.Update)
+
+	if len(hits) != 1 {
+		t.Fatalf("projectile should hit the wall once, got: %v hits", len(hits))
+	}
+	hit := hits[0]
+	if hit.Other != wall || !hit.HasOtherID || hit.OtherID != 7 || hit.Damage != 5 || hit.Data != "bullet" || hit.Component != projectile {
+		t.Errorf("hit message is wrong, got: %+v", hit)
+	}
+	if math.Abs(float64(hit.Point.X-148)) > 1 || hit.Normal != (engo.Point{X: 1, Y: 0}) {
+		t.Errorf("projectile should hit the front of the wall, got: %v, normal %v", hit.Point, hit.Normal)
+	}
+	if len(expired) != 1 || expired[0].Reason != ExpiredHit {
+		t.Fatalf("projectile should expire from the hit, got: %v", expired)
+	}
+	if body.IsActive() || len(sys.entities) != 0 {
+		t.Errorf("expired projectile should be put back in the pool")
+	}
+
+	again := fire(0, &ProjectileComponent{})
+	if again != body || !again.IsActive() || pool.Created != 1 {
+		t.Errorf("pool should reuse the body, created: %v", pool.Created)
+	}
+	if pos := Conv.ToEngoPoint(again.GetPosition()); pos != (engo.Point{}) {
+		t.Errorf("reused body should be moved to the start, got: %v", pos)
+	}
+}
+
+func TestProjectilePierce(t *testing.T) {
+	newTestWorld(t, 0)
+	newTestMailbox(t)
+	(&CollisionSystem{}).New(nil)
+	sys := &ProjectileSystem{}
+	sys.New(nil)
+	var hits []ProjectileHitMessage
+	var expired []ProjectileExpiredMessage
+	engo.Mailbox.Listen("ProjectileHitMessage", func(msg engo.Message) {
+		hits = append(hits, msg.(ProjectileHitMessage))
+	})
+	engo.Mailbox.Listen("ProjectileExpiredMessage", func(msg engo.Message) {
+		expired = append(expired, msg.(ProjectileExpiredMessage))
+	})
+
+	shape := box2d.NewB2CircleShape()
+	shape.M_radius = 0.05
+	pool := &ProjectilePool{Shape: shape, Density: 1}
+	// shoots right at 2400 pixels per second, 40 pixels a frame, which is as
+	// fast as box2d lets bodies go
+	fire := func(y float32, projectile *ProjectileComponent) *box2d.B2Body {
+		projectile.Pool = pool
+		body := pool.Get(engo.Point{X: 0, Y: y}, engo.Point{X: 2400, Y: 0})
+		basic := ecs.NewBasic()
+		sys.Add(&basic, projectile, &Box2dComponent{Body: body})
+		return body
+	}
+
+	first := addStatic(engo.Point{X: 150, Y: 0}, 4, 100, 0)
+	second := addStatic(engo.Point{X: 350, Y: 0}, 4, 100, 0)
+	third := addStatic(engo.Point{X: 550, Y: 0}, 4, 100, 0)
+
+	fire(0, &ProjectileComponent{Pierce: 1})
+	stepFrames(12, stepWorld, sys.Update)
+	if len(hits) != 2 || hits[0].Other != first || hits[1].Other != second {
+		t.Fatalf("projectile should hit the first two walls, got: %v", hits)
+	}
+	if len(expired) != 1 || expired[0].Reason != ExpiredHit {
+		t.Errorf("projectile should expire on the second hit, got: %v", expired)
+	}
+
+	hits = nil
+	expired = nil
+	body := fire(0, &ProjectileComponent{Pierce: 5})
+	stepFrames(16, stepWorld, sys.Update)
+	if len(hits) != 3 || hits[2].Other != third {
+		t.Errorf("projectile should pierce all the walls, got: %v", hits)
+	}
+	if len(expired) != 0 || Conv.ToEngoPoint(body.GetPosition()).X < 600 {
+		t.Errorf("projectile should keep flying, got: %v at %v", expired, body.GetPosition())
+	}
+}
+
+func TestProjectileExpire(t *testing.T) {
+	newTestWorld(t, 0)
+	newTestMailbox(t)
+	(&CollisionSystem{}).New(nil)
+	sys := &ProjectileSystem{}
+	sys.New(nil)
+	var hits []ProjectileHitMessage
+	var expired []ProjectileExpiredMessage
+	engo.Mailbox.Listen("ProjectileHitMessage", func(msg engo.Message) {
+		hits = append(hits, msg.(ProjectileHitMessage))
+	})
+	engo.Mailbox.Listen("ProjectileExpiredMessage", func(msg engo.Message) {
+		expired = append(expired, msg.(ProjectileExpiredMessage))
+	})
+
+	shape := box2d.NewB2CircleShape()
+	shape.M_radius = 0.05
+	pool := &ProjectilePool{Shape: shape, Density: 1}
+	// shoots right at 2400 pixels per second, 40 pixels a frame, which is as
+	// fast as box2d lets bodies go
+	fire := func(y float32, projectile *ProjectileComponent) *box2d.B2Body {
+		projectile.Pool = pool
+		body := pool.Get(engo.Point{X: 0, Y: y}, engo.Point{X: 2400, Y: 0})
+		basic := ecs.NewBasic()
+		sys.Add(&basic, projectile, &Box2dComponent{Body: body})
+		return body
+	}
+
+	fire(0, &ProjectileComponent{Lifetime: 0.49})
+	ranged := &ProjectileComponent{MaxRange: 990}
+	fire(100, ranged)
+
+	stepFrames(24, stepWorld, sys.Update)
+	if len(expired) != 0 {
+		t.Fatalf("projectiles should not have expired yet, got: %v", expired)
+	}
+	stepFrames(1, stepWorld, sys.Update)
+	if len(expired) != 1 || expired[0].Reason != ExpiredRange {
+		t.Fatalf("ranged projectile should expire after 990 pixels, got: %v", expired)
+	}
+	stepFrames(4, stepWorld, sys.Update)
+	if len(expired) != 1 {
+		t.Fatalf("projectile should not have expired yet, got: %v", expired)
+	}
+	stepFrames(1, stepWorld, sys.Update)
+	if len(expired) != 2 || expired[1].Reason != ExpiredLifetime {
+		t.Errorf("projectile should expire after half a second, got: %v", expired)
+	}
+	if ranged.Age < 1.0/6 || pool.Created != 2 {
+		t.Errorf("ranged projectile should have aged, got: %v", ranged.Age)
+	}
+}
@@ -0,0 +1,263 @@
+package engoBox2dSystem
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+)
+
+// ReplayPlayerPriority makes sure the Player sets the pointer before the
+// MouseSystem reads it.
+const ReplayPlayerPriority = MouseSystemPriority + 1
+
+const replayVersion = 2
+
+// ErrReplayVersion is returned by LoadReplay when the file was made by a
+// different version of the Recorder.
+var ErrReplayVersion = errors.New("unsupported replay version")
+
+// ReplayFrame is everything that went into one Update of the PhysicsSystem.
+type ReplayFrame struct {
+	Dt float32
+	// Pointer is what the MouseSystem read from its Source that frame.
+	Pointer Pointer
+	// Bodies are the bodies game code changed since the last step.
+	Bodies []ReplayBody
+}
+
+// ReplayBody is what game code did to a body during a frame. Only the things
+// that changed are set.
+type ReplayBody struct {
+	// Body is the index of the body in the World, in the order they were created.
+	Body int
+
+	Force  box2d.B2Vec2
+	Torque float64
+
+	Velocity *ReplayVelocity
+	Space    *ReplaySpace
+	Awake    *bool
+}
+
+// ReplayVelocity is a body's velocity set by game code, such as by an impulse.
+type ReplayVelocity struct {
+	Linear  box2d.B2Vec2
+	Angular float64
+}
+
+// ReplaySpace is an entity's SpaceComponent moved by game code.
+type ReplaySpace struct {
+	Position engo.Point
+	Rotation float32
+}
+
+type replayFile struct {
+	Version int
+	Frames  []ReplayFrame
+}
+
+type replayBodyState struct {
+	v     box2d.B2Vec2
+	w     float64
+	awake bool
+	space ReplaySpace
+}
+
+// Recorder records the dt, the MouseSystem's pointer, and changes game code
+// makes to the bodies for each Update of a PhysicsSystem. Set it as the
+// system's Recorder, and Save it once you're done.
+type Recorder struct {
+	Frames []ReplayFrame
+
+	last map[*box2d.B2Body]replayBodyState
+}
+
+// capture records the frame that's about to be stepped. It's called before the
+// SpaceComponents are copied to the bodies.
+func (r *Recorder) capture(b *PhysicsSystem, dt float32) {
+	if r.last == nil {
+		r.last = make(map[*box2d.B2Body]replayBodyState)
+	}
+	spaces := b.spaceByBody()
+
+	frame := ReplayFrame{Dt: dt}
+	if b.mouse != nil {
+		frame.Pointer = b.mouse.pointer
+	}
+	for i, bod := range worldBodies() {
+		last, seen := r.last[bod]
+		rb := ReplayBody{
+			Body:   i,
+			Force:  bod.M_force,
+			Torque: bod.M_torque,
+		}
+		if v, w := bod.GetLinearVelocity(), bod.GetAngularVelocity(); !seen || v != last.v || w != last.w {
+			rb.Velocity = &ReplayVelocity{Linear: v, Angular: w}
+		}
+		if awake := bod.IsAwake(); !seen || awake != last.awake {
+			rb.Awake = &awake
+		}
+		if space, ok := spaces[bod]; ok {
+			s := ReplaySpace{Position: space.Position, Rotation: space.Rotation}
+			if !seen || s != last.space {
+				rb.Space = &s
+			}
+		}
+		if rb.Force != (box2d.B2Vec2{}) || rb.Torque != 0 || rb.Velocity != nil || rb.Awake != nil || rb.Space != nil {
+			frame.Bodies = append(frame.Bodies, rb)
+		}
+	}
+	r.Frames = append(r.Frames, frame)
+}
+
+// stepped remembers the state of the bodies after a step, so the next capture
+// only records what game code changed.
+func (r *Recorder) stepped(b *PhysicsSystem) {
+	spaces := b.spaceByBody()
+	last := make(map[*box2d.B2Body]replayBodyState, len(r.last))
+	for _, bod := range worldBodies() {
+		state := replayBodyState{
+			v:     bod.GetLinearVelocity(),
+			w:     bod.GetAngularVelocity(),
+			awake: bod.IsAwake(),
+		}
+		if space, ok := spaces[bod]; ok {
+			state.space = ReplaySpace{Position: space.Position, Rotation: space.Rotation}
+		}
+		last[bod] = state
+	}
+	r.last = last
+}
+
+// Save writes the recorded frames to w.
+func (r *Recorder) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(replayFile{Version: replayVersion, Frames: r.Frames})
+}
+
+// Player is a System that plays back frames made by a Recorder. It's the
+// MouseSystem's Source until it's done, and the PhysicsSystem applies the
+// recorded changes to the bodies right before it steps. Add it to the
+// ecs.World after the MouseSystem. The World has to start out with the same
+// bodies it had when the recording started, and should be a new World, since
+// box2d reuses the broad-phase proxies of destroyed bodies.
+//
+// Call Update on the ecs.World with Dt until Done returns true.
+type Player struct {
+	Frames []ReplayFrame
+
+	frame   int
+	current *ReplayFrame
+
+	mouse   *MouseSystem
+	source  PointerSource
+	pointer Pointer
+}
+
+// LoadReplay reads a replay saved by a Recorder.
+func LoadReplay(r io.Reader) (*Player, error) {
+	var f replayFile
+	if err := gob.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+	if f.Version != replayVersion {
+		return nil, ErrReplayVersion
+	}
+	return &Player{Frames: f.Frames}, nil
+}
+
+// Priority implements the ecs.Prioritizer interface.
+func (p *Player) Priority() int { return ReplayPlayerPriority }
+
+// New hooks the Player up to the World's PhysicsSystem and MouseSystem.
+func (p *Player) New(w *ecs.World) {
+	for _, system := range w.Systems() {
+		switch sys := system.(type) {
+		case *PhysicsSystem:
+			sys.player = p
+		case *MouseSystem:
+			p.mouse = sys
+			p.source = sys.Source
+			sys.Source = p
+		}
+	}
+}
+
+// Remove doesn't do anything, since the Player has no entities.
+func (p *Player) Remove(basic ecs.BasicEntity) {}
+
+// Update sets the pointer to how it was in the next recorded frame. Once it's
+// done, the MouseSystem gets its Source back.
+func (p *Player) Update(dt float32) {
+	if p.Done() {
+		p.current = nil
+		if p.mouse != nil && p.mouse.Source == p {
+			p.mouse.Source = p.source
+		}
+		return
+	}
+	p.current = &p.Frames[p.frame]
+	p.frame++
+	p.pointer = p.current.Pointer
+}
+
+// Pointer implements the PointerSource interface
+func (p *Player) Pointer() Pointer { return p.pointer }
+
+// Dt is the dt of the next frame.
+func (p *Player) Dt() float32 {
+	if p.Done() {
+		return 0
+	}
+	return p.Frames[p.frame].Dt
+}
+
+// Done returns true once every frame has been played.
+func (p *Player) Done() bool {
+	return p.frame >= len(p.Frames)
+}
+
+// apply makes the recorded changes to the bodies. It's called before the
+// SpaceComponents are copied to the bodies.
+func (p *Player) apply(b *PhysicsSystem) {
+	if p.current == nil {
+		return
+	}
+	bodies := worldBodies()
+	spaces := b.spaceByBody()
+	for _, rb := range p.current.Bodies {
+		if rb.Body >= len(bodies) {
+			continue
+		}
+		bod := bodies[rb.Body]
+		if rb.Space != nil {
+			if space, ok := spaces[bod]; ok {
+				space.Position = rb.Space.Position
+				space.Rotation = rb.Space.Rotation
+			}
+		}
+		if rb.Awake != nil {
+			bod.SetAwake(*rb.Awake)
+		}
+		if rb.Velocity != nil {
+			bod.M_linearVelocity = rb.Velocity.Linear
+			bod.M_angularVelocity = rb.Velocity.Angular
+		}
+		bod.M_force = rb.Force
+		bod.M_torque = rb.Torque
+	}
+	p.current = nil
+}
+
+func (b *PhysicsSystem) spaceByBody() map[*box2d.B2Body]*common.SpaceComponent {
+	spaces := make(map[*box2d.B2Body]*common.SpaceComponent, len(b.entities))
+	for _, e := range b.entities {
+		spaces[e.Body] = e.SpaceComponent
+	}
+	return spaces
+}
@@ -0,0 +1,258 @@
+package engoBox2dSystem
+
+import (
+	"bytes"
+	"encoding/gob"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// ReplayTestScene sets up the test stack with a PhysicsSystem and a
+// MouseSystem that watches the first box. If game is set, a replayGameSystem
+// pushes the bodies around the way game code would.
+type ReplayTestScene struct {
+	game bool
+
+	world    *ecs.World
+	phys     *PhysicsSystem
+	entities []physicsEntity
+	mouse    *MouseComponent
+	pointer  *MouseSystem
+}
+
+func (*ReplayTestScene) Preload() {}
+
+func (s *ReplayTestScene) Setup(u engo.Updater) {
+	clearWorld()
+	s.world, _ = u.(*ecs.World)
+	s.phys = &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+	s.entities = newTestStack(s.phys)
+	s.mouse = &MouseComponent{}
+
+	s.pointer = &MouseSystem{}
+	s.world.AddSystem(&common.CameraSystem{})
+	s.world.AddSystem(s.pointer)
+	box := s.entities[1]
+	s.pointer.Add(box.BasicEntity, s.mouse, box.SpaceComponent, nil, box.Box2dComponent)
+	if s.game {
+		s.world.AddSystem(&replayGameSystem{s})
+	}
+	s.world.AddSystem(s.phys)
+}
+
+func (*ReplayTestScene) Type() string { return "ReplayTestScene" }
+
+type replayGameSystem struct {
+	scene *ReplayTestScene
+}
+
+func (*replayGameSystem) Remove(ecs.BasicEntity) {}
+
+func (g *replayGameSystem) Update(dt float32) {
+	if g.scene.mouse.Clicked {
+		g.scene.entities[1].Body.ApplyLinearImpulseToCenter(box2d.B2Vec2{X: 0.5, Y: -2}, true)
+	}
+	g.scene.entities[6].Body.ApplyForce(box2d.B2Vec2{X: 0.3, Y: 0}, g.scene.entities[6].Body.GetWorldPoint(box2d.B2Vec2{X: 0, Y: 0.1}), true)
+}
+
+func runReplayScene(scene *ReplayTestScene) {
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, scene)
+}
+
+// recordReplayScene plays the scene while clicking on the first box, and
+// teleporting the wheel partway through.
+func recordReplayScene() (*Recorder, string) {
+	scene := &ReplayTestScene{game: true}
+	runReplayScene(scene)
+	rec := &Recorder{}
+	scene.phys.Recorder = rec
+
+	for i := 0; i < 60; i++ {
+		box := scene.entities[1].SpaceComponent.Center()
+		engo.Input.Mouse = engo.Mouse{X: box.X, Y: box.Y, Action: engo.Move}
+		switch i {
+		case 20:
+			engo.Input.Mouse.Action = engo.Press
+			engo.Input.Mouse.Button = engo.MouseButtonLeft
+		case 21:
+			engo.Input.Mouse.Action = engo.Release
+			engo.Input.Mouse.Button = engo.MouseButtonLeft
+		case 40:
+			scene.entities[5].SpaceComponent.Position.X += 30
+		}
+		scene.world.Update(1.0 / 60.0)
+	}
+	return rec, replayBodyStates(scene.entities)
+}
+
+func playReplayScene(p *Player) string {
+	scene := &ReplayTestScene{}
+	runReplayScene(scene)
+	scene.world.AddSystem(p)
+	for !p.Done() {
+		scene.world.Update(p.Dt())
+	}
+	return replayBodyStates(scene.entities)
+}
+
+// replayBodyStates writes out the exact state of each body.
+func replayBodyStates(entities []physicsEntity) string {
+	var buf bytes.Buffer
+	for i, e := range entities {
+		xf := e.Body.GetTransform()
+		v := e.Body.GetLinearVelocity()
+		fmt.Fprintf(&buf, "%d: p=(%v, %v) a=%v v=(%v, %v) w=%v awake=%v\n",
+			i, xf.P.X, xf.P.Y, e.Body.GetAngle(), v.X, v.Y, e.Body.GetAngularVelocity(), e.Body.IsAwake())
+	}
+	return buf.String()
+}
+
+func TestRecorderPlayback(t *testing.T) {
+	defer clearWorld()
+
+	rec, want := recordReplayScene()
+	if len(rec.Frames) != 60 {
+		t.Fatalf("wrong number of frames recorded, want: %d, got: %d", 60, len(rec.Frames))
+	}
+	if rec.Frames[20].Pointer.Action != engo.Press {
+		t.Errorf("mouse press was not recorded")
+	}
+
+	var file bytes.Buffer
+	if err := rec.Save(&file); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	p, err := LoadReplay(&file)
+	if err != nil {
+		t.Fatalf("LoadReplay returned an error: %v", err)
+	}
+
+	if got := playReplayScene(p); got != want {
+		t.Errorf("playback did not match the recording\nwant:\n%sgot:\n%s", want, got)
+	}
+
+	// without the changes to the bodies, the game code is lost
+	stripped := &Player{}
+	for _, frame := range rec.Frames {
+		stripped.Frames = append(stripped.Frames, ReplayFrame{Dt: frame.Dt, Pointer: frame.Pointer})
+	}
+	if got := playReplayScene(stripped); got == want {
+		t.Errorf("playback without the recorded bodies should not match the recording")
+	}
+}
+
+// the pointer is recorded from the MouseSystem's Source and played back into
+// it, whatever the engo mouse is doing
+func TestRecorderPointerSource(t *testing.T) {
+	defer clearWorld()
+
+	scene := &ReplayTestScene{game: true}
+	runReplayScene(scene)
+	rec := &Recorder{}
+	scene.phys.Recorder = rec
+	engo.Input.Mouse = engo.Mouse{X: 90, Y: 90, Action: engo.Move}
+
+	script := &ScriptedPointer{}
+	box := scene.entities[1].SpaceComponent.Center()
+	for i := 0; i < 30; i++ {
+		p := Pointer{X: box.X, Y: box.Y, Action: engo.Move}
+		switch i {
+		case 10:
+			p.Action, p.Button = engo.Press, engo.MouseButtonLeft
+		case 11:
+			p.Action, p.Button = engo.Release, engo.MouseButtonLeft
+		}
+		script.Push(p)
+	}
+	scene.pointer.Source = script
+	for i := 0; i < 30; i++ {
+		scene.world.Update(1.0 / 60.0)
+	}
+	if p := rec.Frames[10].Pointer; p.Action != engo.Press || p.X != box.X || p.Y != box.Y {
+		t.Fatalf("the pointer the MouseSystem read was not recorded, got: %+v", p)
+	}
+
+	played := &ReplayTestScene{}
+	runReplayScene(played)
+	other := &ScriptedPointer{}
+	played.pointer.Source = other
+	p := &Player{Frames: rec.Frames}
+	played.world.AddSystem(p)
+	clicked := false
+	for !p.Done() {
+		played.world.Update(p.Dt())
+		clicked = clicked || played.mouse.Clicked
+	}
+	if !clicked {
+		t.Errorf("the recorded pointer was not played into the MouseSystem")
+	}
+	played.world.Update(1.0 / 60.0)
+	if played.pointer.Source != other {
+		t.Errorf("the MouseSystem should get its Source back once the Player is done")
+	}
+}
+
+// TestReplayGolden plays back a captured level and compares the final state
+// with the golden file. Run with -update to record them again.
+func TestReplayGolden(t *testing.T) {
+	defer clearWorld()
+	replayPath := filepath.Join("testdata", "stack.replay")
+	goldenPath := filepath.Join("testdata", "stack.golden")
+
+	if *updateGolden {
+		rec, states := recordReplayScene()
+		var file bytes.Buffer
+		if err := rec.Save(&file); err != nil {
+			t.Fatalf("Save returned an error: %v", err)
+		}
+		if err := os.WriteFile(replayPath, file.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenPath, []byte(states), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(replayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p, err := LoadReplay(f)
+	if err != nil {
+		t.Fatalf("LoadReplay returned an error: %v", err)
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := playReplayScene(p); got != string(want) {
+		t.Errorf("playback did not match the golden file\nwant:\n%sgot:\n%s", want, got)
+	}
+}
+
+func TestLoadReplayVersion(t *testing.T) {
+	var file bytes.Buffer
+	if err := gob.NewEncoder(&file).Encode(replayFile{Version: replayVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadReplay(&file); err != ErrReplayVersion {
+		t.Errorf("wrong error for a newer replay, want: %v, got: %v", ErrReplayVersion, err)
+	}
+}
@@ -0,0 +1,81 @@
+package engoBox2dSystem
+
+import "errors"
+
+// ErrRewindTooFar is returned by Rewind when the system hasn't kept enough
+// snapshots to go back the requested number of frames.
+var ErrRewindTooFar = errors.New("not enough frames kept to rewind that far")
+
+// replaying is set while the PhysicsSystem is re-simulating frames after a
+// Rewind. The CollisionSystem doesn't send out CollisionStartMessages or
+// CollisionEndMessages while it is set, since they were already sent the first
+// time the frames were simulated. PreSolveMessages and PostSolveMessages are
+// still sent, since their listeners can change how the contacts are solved.
+var replaying bool
+
+// rollbackHistory is a ring buffer of the snapshots taken after each Update.
+type rollbackHistory struct {
+	frames []*WorldSnapshot
+	start  int
+	count  int
+}
+
+func (h *rollbackHistory) push(s *WorldSnapshot, size int) {
+	if len(h.frames) != size {
+		h.frames, h.start, h.count = make([]*WorldSnapshot, size), 0, 0
+	}
+	if h.count < size {
+		h.frames[(h.start+h.count)%size] = s
+		h.count++
+		return
+	}
+	h.frames[h.start] = s
+	h.start = (h.start + 1) % size
+}
+
+// get returns the snapshot taken back frames Updates ago.
+func (h *rollbackHistory) get(back int) *WorldSnapshot {
+	return h.frames[(h.start+h.count-1-back)%len(h.frames)]
+}
+
+// record saves the state of the World at the end of an Update. The first
+// Update also saves the state the World was in before it.
+func (b *PhysicsSystem) record() {
+	if b.RollbackFrames <= 0 {
+		b.history = rollbackHistory{}
+		return
+	}
+	b.history.push(b.Snapshot(), b.RollbackFrames+1)
+}
+
+// Rewind sets the World back to how it was the given number of Updates ago.
+//
+// Rewind doesn't re-simulate anything itself. When it returns, the World is in
+// the past, and it's the next frames calls to Update that bring it back up to
+// the present, one frame each, so set the corrected inputs before each of
+// those Updates. To catch up within a single engo frame, call Update frames
+// times in a row right after Rewind. Replaying reports whether there are
+// frames left to re-simulate. Collision start and end messages aren't sent
+// out while they are, but pre and post solve messages are.
+//
+// RollbackFrames must be set before the frames to rewind are simulated. If
+// bodies, fixtures, or joints were created or destroyed since then,
+// ErrSnapshotMismatch is returned and the World is left alone.
+func (b *PhysicsSystem) Rewind(frames int) error {
+	if frames < 0 || frames >= b.history.count {
+		return ErrRewindTooFar
+	}
+	if err := b.Restore(b.history.get(frames)); err != nil {
+		return err
+	}
+	b.history.count -= frames
+	b.steps -= uint64(frames)
+	b.replayFrames = frames
+	return nil
+}
+
+// Replaying returns true while the system is re-simulating frames after a
+// Rewind.
+func (b *PhysicsSystem) Replaying() bool {
+	return b.replayFrames > 0
+}
@@ -0,0 +1,122 @@
+package engoBox2dSystem
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+)
+
+func TestRewind(t *testing.T) {
+	newTestWorld(t, 0)
+	updateTime := float32(1.0 / 60.0)
+
+	var messages, preSolves int
+	newTestMailbox(t)
+	engo.Mailbox.Listen("CollisionStartMessage", func(engo.Message) { messages++ })
+	engo.Mailbox.Listen("PreSolveMessage", func(engo.Message) { preSolves++ })
+	coll := &CollisionSystem{}
+	coll.New(nil)
+	defer World.SetContactListener(nil)
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3, RollbackFrames: 10}
+	entities := newTestStack(phys)
+
+	if err := phys.Rewind(1); err != ErrRewindTooFar {
+		t.Errorf("Rewind before any Updates should fail, want: %v, got: %v", ErrRewindTooFar, err)
+	}
+
+	var states [][]testBodyState
+	for i := 0; i < 40; i++ {
+		phys.Update(updateTime)
+		states = append(states, testBodyStates(entities))
+	}
+	if messages == 0 {
+		t.Fatal("test stack sent no collision messages")
+	}
+
+	if err := phys.Rewind(11); err != ErrRewindTooFar {
+		t.Errorf("Rewind past RollbackFrames should fail, want: %v, got: %v", ErrRewindTooFar, err)
+	}
+
+	if err := phys.Rewind(5); err != nil {
+		t.Fatalf("Rewind returned an error: %v", err)
+	}
+	compareBodyStates(t, states[34], testBodyStates(entities))
+	if !phys.Replaying() {
+		t.Error("system should be replaying after a Rewind")
+	}
+
+	messages, preSolves = 0, 0
+	for i := 35; i < 40; i++ {
+		phys.Update(updateTime)
+		compareBodyStates(t, states[i], testBodyStates(entities))
+	}
+	if messages != 0 {
+		t.Errorf("collision messages were sent while replaying, got: %d", messages)
+	}
+	if preSolves == 0 {
+		t.Error("pre solve messages were not sent while replaying")
+	}
+	if phys.Replaying() {
+		t.Error("system should be done replaying")
+	}
+
+	// the replayed frames are kept, so it can rewind all the way again
+	if err := phys.Rewind(10); err != nil {
+		t.Fatalf("Rewind after replaying returned an error: %v", err)
+	}
+	compareBodyStates(t, states[29], testBodyStates(entities))
+	for i := 30; i < 40; i++ {
+		phys.Update(updateTime)
+	}
+	compareBodyStates(t, states[39], testBodyStates(entities))
+
+	phys.Update(updateTime)
+	if messages == 0 {
+		t.Error("collision messages were not sent after replaying")
+	}
+}
+
+func TestRewindHash(t *testing.T) {
+	newTestWorld(t, 0)
+	updateTime := float32(1.0 / 60.0)
+
+	newTestMailbox(t)
+	coll := &CollisionSystem{}
+	coll.New(nil)
+	defer World.SetContactListener(nil)
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3, RollbackFrames: 10, HashSteps: true}
+	entities := newTestStack(phys)
+
+	// the top box falls through everything, which only happens if the
+	// listener gets to disable its contacts while replaying too
+	ghost := entities[len(entities)-1].Body
+	engo.Mailbox.Listen("PreSolveMessage", func(msg engo.Message) {
+		contact := msg.(PreSolveMessage).Contact
+		if contact.GetFixtureA().GetBody() == ghost || contact.GetFixtureB().GetBody() == ghost {
+			contact.SetEnabled(false)
+		}
+	})
+	hashes := make(map[uint64]uint64)
+	engo.Mailbox.Listen("StepHashMessage", func(msg engo.Message) {
+		m := msg.(StepHashMessage)
+		if want, ok := hashes[m.Step]; ok && want != m.Hash {
+			t.Errorf("re-simulated step %d has a different hash, want: %x, got: %x", m.Step, want, m.Hash)
+		}
+		hashes[m.Step] = m.Hash
+	})
+
+	for i := 0; i < 40; i++ {
+		phys.Update(updateTime)
+	}
+	if err := phys.Rewind(10); err != nil {
+		t.Fatalf("Rewind returned an error: %v", err)
+	}
+	for phys.Replaying() {
+		phys.Update(updateTime)
+	}
+	if len(hashes) != 40 {
+		t.Errorf("wrong number of steps hashed, want: %d, got: %d", 40, len(hashes))
+	}
+}
@@ -0,0 +1,517 @@
+package rube
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ByteArena/box2d"
+)
+
+type rubeCustomProperty struct {
+	Name   string    `json:"name"`
+	Int    *int      `json:"int,omitempty"`
+	Float  *float64  `json:"float,omitempty"`
+	String *string   `json:"string,omitempty"`
+	Bool   *bool     `json:"bool,omitempty"`
+	Vec2   *rubeVec2 `json:"vec2,omitempty"`
+	Color  []int     `json:"color,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (p CustomProperty) MarshalJSON() ([]byte, error) {
+	rp := rubeCustomProperty{
+		Name:   p.Name,
+		Int:    p.Int,
+		Float:  p.Float,
+		String: p.String,
+		Bool:   p.Bool,
+		Color:  p.Color,
+	}
+	if p.Vec2 != nil {
+		v := vec2(*p.Vec2)
+		rp.Vec2 = &v
+	}
+	return json.Marshal(rp)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *CustomProperty) UnmarshalJSON(data []byte) error {
+	var rp rubeCustomProperty
+	if err := json.Unmarshal(data, &rp); err != nil {
+		return err
+	}
+	*p = CustomProperty{
+		Name:   rp.Name,
+		Int:    rp.Int,
+		Float:  rp.Float,
+		String: rp.String,
+		Bool:   rp.Bool,
+		Color:  rp.Color,
+	}
+	if rp.Vec2 != nil {
+		v := rp.Vec2.b2()
+		p.Vec2 = &v
+	}
+	return nil
+}
+
+// rubeVec2 is a vector as RUBE writes it: either an object with x and y, or
+// the number 0 for the zero vector.
+type rubeVec2 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func vec2(v box2d.B2Vec2) rubeVec2 { return rubeVec2{v.X, v.Y} }
+
+func vec2Ptr(v box2d.B2Vec2) *rubeVec2 { return &rubeVec2{v.X, v.Y} }
+
+func (v rubeVec2) b2() box2d.B2Vec2 { return box2d.B2Vec2{X: v.X, Y: v.Y} }
+
+// MarshalJSON implements json.Marshaler
+func (v rubeVec2) MarshalJSON() ([]byte, error) {
+	if v.X == 0 && v.Y == 0 {
+		return []byte("0"), nil
+	}
+	type plain rubeVec2
+	return json.Marshal(plain(v))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (v *rubeVec2) UnmarshalJSON(data []byte) error {
+	if string(data) == "0" {
+		*v = rubeVec2{}
+		return nil
+	}
+	type plain rubeVec2
+	return json.Unmarshal(data, (*plain)(v))
+}
+
+type rubeVertexList struct {
+	X []float64 `json:"x"`
+	Y []float64 `json:"y"`
+}
+
+func verticesOf(vs []box2d.B2Vec2) rubeVertexList {
+	var l rubeVertexList
+	for _, v := range vs {
+		l.X = append(l.X, v.X)
+		l.Y = append(l.Y, v.Y)
+	}
+	return l
+}
+
+func (l rubeVertexList) b2() ([]box2d.B2Vec2, error) {
+	if len(l.X) != len(l.Y) {
+		return nil, fmt.Errorf("vertex list has %d x values and %d y values", len(l.X), len(l.Y))
+	}
+	vs := make([]box2d.B2Vec2, len(l.X))
+	for i := range l.X {
+		vs[i] = box2d.B2Vec2{X: l.X[i], Y: l.Y[i]}
+	}
+	return vs, nil
+}
+
+type rubeWorld struct {
+	Gravity            rubeVec2         `json:"gravity"`
+	AllowSleep         bool             `json:"allowSleep"`
+	AutoClearForces    bool             `json:"autoClearForces"`
+	PositionIterations int              `json:"positionIterations"`
+	VelocityIterations int              `json:"velocityIterations"`
+	StepsPerSecond     int              `json:"stepsPerSecond"`
+	WarmStarting       bool             `json:"warmStarting"`
+	ContinuousPhysics  bool             `json:"continuousPhysics"`
+	SubStepping        bool             `json:"subStepping"`
+	Bodies             []rubeBody       `json:"body,omitempty"`
+	Joints             []rubeJoint      `json:"joint,omitempty"`
+	Images             []rubeImage      `json:"image,omitempty"`
+	CustomProperties   []CustomProperty `json:"customProperties,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, filling in RUBE's defaults for
+// missing values.
+func (w *rubeWorld) UnmarshalJSON(data []byte) error {
+	type plain rubeWorld
+	p := plain{
+		AllowSleep:         true,
+		AutoClearForces:    true,
+		PositionIterations: 3,
+		VelocityIterations: 8,
+		StepsPerSecond:     60,
+		WarmStarting:       true,
+		ContinuousPhysics:  true,
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*w = rubeWorld(p)
+	return nil
+}
+
+// validate checks the bodies and joints for what box2d would panic on, before
+// anything is added to the World.
+func (w rubeWorld) validate() error {
+	for i, rb := range w.Bodies {
+		if rb.Type > box2d.B2BodyType.B2_dynamicBody {
+			return fmt.Errorf("body %d: unknown body type %d", i, rb.Type)
+		}
+	}
+	for i, rj := range w.Joints {
+		if rj.BodyA < 0 || rj.BodyA >= len(w.Bodies) || rj.BodyB < 0 || rj.BodyB >= len(w.Bodies) {
+			return fmt.Errorf("joint %d: body index out of range", i)
+		}
+		if rj.BodyA == rj.BodyB {
+			return fmt.Errorf("joint %d: joins body %d to itself", i, rj.BodyA)
+		}
+	}
+	return nil
+}
+
+// flipY mirrors the scene across the x axis, turning the editor's y up into
+// engo's y down and back. Positions and axes have their y negated, and angles
+// and angular speeds are negated, since mirroring turns clockwise into counter
+// clockwise. Custom property values are left alone.
+func (w *rubeWorld) flipY() {
+	w.Gravity.Y = -w.Gravity.Y
+	for i := range w.Bodies {
+		rb := &w.Bodies[i]
+		rb.Position.Y = -rb.Position.Y
+		rb.Angle = -rb.Angle
+		rb.LinearVelocity.Y = -rb.LinearVelocity.Y
+		rb.AngularVelocity = -rb.AngularVelocity
+		rb.MassCenter.Y = -rb.MassCenter.Y
+		for j := range rb.Fixtures {
+			rb.Fixtures[j].flipY()
+		}
+	}
+	for i := range w.Joints {
+		rj := &w.Joints[i]
+		rj.AnchorA.Y = -rj.AnchorA.Y
+		rj.AnchorB.Y = -rj.AnchorB.Y
+		rj.LocalAxisA.Y = -rj.LocalAxisA.Y
+		rj.GroundAnchorA.Y = -rj.GroundAnchorA.Y
+		rj.GroundAnchorB.Y = -rj.GroundAnchorB.Y
+		rj.LinearOffset.Y = -rj.LinearOffset.Y
+		rj.Target.Y = -rj.Target.Y
+		rj.RefAngle = -rj.RefAngle
+		switch rj.Type {
+		case "revolute":
+			rj.LowerLimit, rj.UpperLimit = -rj.UpperLimit, -rj.LowerLimit
+			rj.MotorSpeed = -rj.MotorSpeed
+		case "wheel":
+			rj.MotorSpeed = -rj.MotorSpeed
+		}
+	}
+	for i := range w.Images {
+		w.Images[i].Center.Y = -w.Images[i].Center.Y
+		w.Images[i].Angle = -w.Images[i].Angle
+	}
+}
+
+func (f *rubeFixture) flipY() {
+	switch {
+	case f.Circle != nil:
+		f.Circle.Center.Y = -f.Circle.Center.Y
+	case f.Polygon != nil:
+		// reversed as well, so they stay counter clockwise
+		vs := &f.Polygon.Vertices
+		if len(vs.X) != len(vs.Y) {
+			return // shape reports it
+		}
+		for i, j := 0, len(vs.Y)-1; i < j; i, j = i+1, j-1 {
+			vs.X[i], vs.X[j] = vs.X[j], vs.X[i]
+			vs.Y[i], vs.Y[j] = vs.Y[j], vs.Y[i]
+		}
+		for i := range vs.Y {
+			vs.Y[i] = -vs.Y[i]
+		}
+	case f.Chain != nil:
+		for i := range f.Chain.Vertices.Y {
+			f.Chain.Vertices.Y[i] = -f.Chain.Vertices.Y[i]
+		}
+		if f.Chain.PrevVertex != nil {
+			f.Chain.PrevVertex.Y = -f.Chain.PrevVertex.Y
+		}
+		if f.Chain.NextVertex != nil {
+			f.Chain.NextVertex.Y = -f.Chain.NextVertex.Y
+		}
+	}
+}
+
+type rubeBody struct {
+	Name             string           `json:"name,omitempty"`
+	Type             uint8            `json:"type"`
+	Position         rubeVec2         `json:"position"`
+	Angle            float64          `json:"angle"`
+	LinearVelocity   rubeVec2         `json:"linearVelocity"`
+	AngularVelocity  float64          `json:"angularVelocity"`
+	LinearDamping    float64          `json:"linearDamping"`
+	AngularDamping   float64          `json:"angularDamping"`
+	AllowSleep       bool             `json:"allowSleep"`
+	Awake            bool             `json:"awake"`
+	FixedRotation    bool             `json:"fixedRotation"`
+	Bullet           bool             `json:"bullet"`
+	Active           bool             `json:"active"`
+	GravityScale     float64          `json:"gravityScale"`
+	Mass             *float64         `json:"massData-mass,omitempty"`
+	MassCenter       rubeVec2         `json:"massData-center,omitempty"`
+	MassI            float64          `json:"massData-I,omitempty"`
+	Fixtures         []rubeFixture    `json:"fixture,omitempty"`
+	CustomProperties []CustomProperty `json:"customProperties,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, filling in RUBE's defaults for
+// missing values.
+func (b *rubeBody) UnmarshalJSON(data []byte) error {
+	type plain rubeBody
+	p := plain{
+		AllowSleep:   true,
+		Active:       true,
+		GravityScale: 1,
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*b = rubeBody(p)
+	return nil
+}
+
+type rubeFixture struct {
+	Name             string           `json:"name,omitempty"`
+	Density          float64          `json:"density"`
+	Friction         float64          `json:"friction"`
+	Restitution      float64          `json:"restitution"`
+	Sensor           bool             `json:"sensor"`
+	CategoryBits     uint16           `json:"filter-categoryBits"`
+	MaskBits         uint16           `json:"filter-maskBits"`
+	GroupIndex       int16            `json:"filter-groupIndex"`
+	Circle           *rubeCircle      `json:"circle,omitempty"`
+	Polygon          *rubeVertices    `json:"polygon,omitempty"`
+	Chain            *rubeChain       `json:"chain,omitempty"`
+	CustomProperties []CustomProperty `json:"customProperties,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, filling in RUBE's defaults for
+// missing values.
+func (f *rubeFixture) UnmarshalJSON(data []byte) error {
+	type plain rubeFixture
+	p := plain{
+		CategoryBits: 0x0001,
+		MaskBits:     0xFFFF,
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*f = rubeFixture(p)
+	return nil
+}
+
+func (f rubeFixture) shape() (box2d.B2ShapeInterface, error) {
+	switch {
+	case f.Circle != nil:
+		if f.Circle.Radius <= 0 {
+			return nil, fmt.Errorf("circle has radius %v", f.Circle.Radius)
+		}
+		s := box2d.NewB2CircleShape()
+		s.M_p = f.Circle.Center.b2()
+		s.M_radius = f.Circle.Radius
+		return s, nil
+	case f.Polygon != nil:
+		vs, err := f.Polygon.Vertices.b2()
+		if err != nil {
+			return nil, err
+		}
+		if len(vs) < 3 || len(vs) > box2d.B2_maxPolygonVertices {
+			return nil, fmt.Errorf("polygon has %d vertices", len(vs))
+		}
+		if !convexHullOK(vs) {
+			return nil, fmt.Errorf("polygon has no area")
+		}
+		s := box2d.NewB2PolygonShape()
+		s.Set(vs, len(vs))
+		return s, nil
+	case f.Chain != nil:
+		vs, err := f.Chain.Vertices.b2()
+		if err != nil {
+			return nil, err
+		}
+		if len(vs) < 2 {
+			return nil, fmt.Errorf("chain has %d vertices", len(vs))
+		}
+		for i := 1; i < len(vs); i++ {
+			if box2d.B2Vec2DistanceSquared(vs[i-1], vs[i]) <= box2d.B2_linearSlop*box2d.B2_linearSlop {
+				return nil, fmt.Errorf("chain vertices %d and %d are too close together", i-1, i)
+			}
+		}
+		if len(vs) == 2 {
+			s := box2d.NewB2EdgeShape()
+			s.Set(vs[0], vs[1])
+			if f.Chain.PrevVertex != nil {
+				s.M_hasVertex0, s.M_vertex0 = f.Chain.HasPrevVertex, f.Chain.PrevVertex.b2()
+			}
+			if f.Chain.NextVertex != nil {
+				s.M_hasVertex3, s.M_vertex3 = f.Chain.HasNextVertex, f.Chain.NextVertex.b2()
+			}
+			return s, nil
+		}
+		s := box2d.MakeB2ChainShape()
+		s.CreateChain(vs, len(vs))
+		if f.Chain.HasPrevVertex && f.Chain.PrevVertex != nil {
+			s.SetPrevVertex(f.Chain.PrevVertex.b2())
+		}
+		if f.Chain.HasNextVertex && f.Chain.NextVertex != nil {
+			s.SetNextVertex(f.Chain.NextVertex.b2())
+		}
+		return &s, nil
+	}
+	return nil, fmt.Errorf("fixture has no shape")
+}
+
+// convexHullOK checks that box2d can make a polygon from the vertices, by
+// welding and wrapping them the way B2PolygonShape.Set does. Set panics if the
+// hull has fewer than 3 points or no area.
+func convexHullOK(vertices []box2d.B2Vec2) bool {
+	const weld = 0.5 * box2d.B2_linearSlop
+	var ps []box2d.B2Vec2
+	for _, v := range vertices {
+		unique := true
+		for _, p := range ps {
+			if box2d.B2Vec2DistanceSquared(v, p) < weld*weld {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			ps = append(ps, v)
+		}
+	}
+	if len(ps) < 3 {
+		return false
+	}
+
+	i0 := 0
+	for i := 1; i < len(ps); i++ {
+		if x := ps[i].X; x > ps[i0].X || (x == ps[i0].X && ps[i].Y < ps[i0].Y) {
+			i0 = i
+		}
+	}
+	var hull []box2d.B2Vec2
+	for ih := i0; ; {
+		if len(hull) == box2d.B2_maxPolygonVertices {
+			return false
+		}
+		hull = append(hull, ps[ih])
+		ie := 0
+		for j := 1; j < len(ps); j++ {
+			if ie == ih {
+				ie = j
+				continue
+			}
+			r := box2d.B2Vec2Sub(ps[ie], ps[ih])
+			v := box2d.B2Vec2Sub(ps[j], ps[ih])
+			c := box2d.B2Vec2Cross(r, v)
+			if c < 0 || c == 0 && v.LengthSquared() > r.LengthSquared() {
+				ie = j
+			}
+		}
+		ih = ie
+		if ie == i0 {
+			break
+		}
+	}
+	if len(hull) < 3 {
+		return false
+	}
+
+	var area float64
+	for i, a := range hull {
+		area += box2d.B2Vec2Cross(a, hull[(i+1)%len(hull)])
+	}
+	return area/2 > box2d.B2_epsilon
+}
+
+type rubeCircle struct {
+	Center rubeVec2 `json:"center"`
+	Radius float64  `json:"radius"`
+}
+
+type rubeVertices struct {
+	Vertices rubeVertexList `json:"vertices"`
+}
+
+type rubeChain struct {
+	Vertices      rubeVertexList `json:"vertices"`
+	HasPrevVertex bool           `json:"hasPrevVertex,omitempty"`
+	HasNextVertex bool           `json:"hasNextVertex,omitempty"`
+	PrevVertex    *rubeVec2      `json:"prevVertex,omitempty"`
+	NextVertex    *rubeVec2      `json:"nextVertex,omitempty"`
+}
+
+type rubeJoint struct {
+	Type               string           `json:"type"`
+	Name               string           `json:"name,omitempty"`
+	BodyA              int              `json:"bodyA"`
+	BodyB              int              `json:"bodyB"`
+	CollideConnected   bool             `json:"collideConnected,omitempty"`
+	AnchorA            rubeVec2         `json:"anchorA"`
+	AnchorB            rubeVec2         `json:"anchorB"`
+	LocalAxisA         rubeVec2         `json:"localAxisA"`
+	RefAngle           float64          `json:"refAngle,omitempty"`
+	EnableLimit        bool             `json:"enableLimit,omitempty"`
+	LowerLimit         float64          `json:"lowerLimit,omitempty"`
+	UpperLimit         float64          `json:"upperLimit,omitempty"`
+	EnableMotor        bool             `json:"enableMotor,omitempty"`
+	MotorSpeed         float64          `json:"motorSpeed,omitempty"`
+	MaxMotorTorque     float64          `json:"maxMotorTorque,omitempty"`
+	MaxMotorForce      float64          `json:"maxMotorForce,omitempty"`
+	Length             float64          `json:"length,omitempty"`
+	Frequency          float64          `json:"frequency,omitempty"`
+	DampingRatio       float64          `json:"dampingRatio,omitempty"`
+	SpringFrequency    float64          `json:"springFrequency,omitempty"`
+	SpringDampingRatio float64          `json:"springDampingRatio,omitempty"`
+	GroundAnchorA      rubeVec2         `json:"groundAnchorA"`
+	GroundAnchorB      rubeVec2         `json:"groundAnchorB"`
+	LengthA            float64          `json:"lengthA,omitempty"`
+	LengthB            float64          `json:"lengthB,omitempty"`
+	Ratio              float64          `json:"ratio,omitempty"`
+	MaxForce           float64          `json:"maxForce,omitempty"`
+	MaxTorque          float64          `json:"maxTorque,omitempty"`
+	MaxLength          float64          `json:"maxLength,omitempty"`
+	LinearOffset       rubeVec2         `json:"linearOffset"`
+	CorrectionFactor   float64          `json:"correctionFactor,omitempty"`
+	Target             rubeVec2         `json:"target"`
+	CustomProperties   []CustomProperty `json:"customProperties,omitempty"`
+}
+
+type rubeImage struct {
+	Name             string           `json:"name,omitempty"`
+	File             string           `json:"file"`
+	Body             int              `json:"body"`
+	Center           rubeVec2         `json:"center"`
+	Angle            float64          `json:"angle"`
+	Scale            float64          `json:"scale"`
+	AspectScale      float64          `json:"aspectScale"`
+	Flip             bool             `json:"flip"`
+	Opacity          float64          `json:"opacity"`
+	Filter           int              `json:"filter"`
+	RenderOrder      float64          `json:"renderOrder"`
+	ColorTint        []int            `json:"colorTint,omitempty"`
+	CustomProperties []CustomProperty `json:"customProperties,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, filling in RUBE's defaults for
+// missing values.
+func (i *rubeImage) UnmarshalJSON(data []byte) error {
+	type plain rubeImage
+	p := plain{
+		Body:        -1,
+		Scale:       1,
+		AspectScale: 1,
+		Opacity:     1,
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*i = rubeImage(p)
+	return nil
+}
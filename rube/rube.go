@@ -0,0 +1,700 @@
+// Package rube loads and saves RUBE json scenes into a box2d World. It only
+// needs box2d, so it can be used without engo, such as by the box2dsim command.
+// The engoBox2dSystem package's LoadScene and SaveScene use it with its World.
+package rube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ByteArena/box2d"
+)
+
+// Scene is the result of loading a RUBE scene with a Loader.
+type Scene struct {
+	// Bodies are the bodies of the scene, in the order they appear in the file.
+	Bodies []*Body
+	// Joints are the joints of the scene, in the order they appear in the file.
+	Joints []*Joint
+	// Images are the images of the scene. Images that are attached to a body
+	// have their Body set.
+	Images []*Image
+	// CustomProperties are the properties set on the scene itself.
+	CustomProperties []CustomProperty
+
+	// VelocityIterations, PositionIterations, and StepsPerSecond are the
+	// simulation settings stored with the scene. They are not applied to the
+	// World; use them when stepping it.
+	VelocityIterations, PositionIterations, StepsPerSecond int
+}
+
+// Body is a body loaded from a scene.
+type Body struct {
+	// Body is the box2d body
+	Body *box2d.B2Body
+	// Name is the name given to the body in the editor.
+	Name string
+	// CustomProperties are the properties set on the body in the editor.
+	CustomProperties []CustomProperty
+}
+
+// Joint is a joint loaded from a scene.
+type Joint struct {
+	// Joint is the box2d joint
+	Joint box2d.B2JointInterface
+	// Name is the name given to the joint in the editor.
+	Name string
+	// CustomProperties are the properties set on the joint in the editor.
+	CustomProperties []CustomProperty
+}
+
+// Image is an image placed in the editor. Positions and sizes are in box2d
+// units; if Body is set they are relative to the body.
+type Image struct {
+	Name             string
+	File             string
+	Body             *box2d.B2Body
+	Center           box2d.B2Vec2
+	Angle            float64
+	Scale            float64
+	AspectScale      float64
+	Flip             bool
+	Opacity          float64
+	Filter           int
+	RenderOrder      float64
+	ColorTint        []int
+	CustomProperties []CustomProperty
+}
+
+// CustomProperty is a named value set in the editor. Exactly one of the values
+// is set.
+type CustomProperty struct {
+	Name   string
+	Int    *int
+	Float  *float64
+	String *string
+	Bool   *bool
+	Vec2   *box2d.B2Vec2
+	Color  []int
+}
+
+// Loader loads scenes into a World, and keeps the editor data that box2d has
+// no place for, such as names and images, so Save can write it back out.
+type Loader struct {
+	World *box2d.B2World
+	// FlipY mirrors scenes across the x axis as they're loaded and saved, for
+	// a World whose y axis points down, like engo's, while the editor's
+	// points up. Angles are negated along with the y values.
+	FlipY bool
+
+	bodies   map[*box2d.B2Body]*bodyInfo
+	fixtures map[*box2d.B2Fixture]*named
+	joints   map[box2d.B2JointInterface]*named
+	images   []*Image
+	props    []CustomProperty
+
+	velocityIterations, positionIterations, stepsPerSecond int
+}
+
+type named struct {
+	name  string
+	props []CustomProperty
+}
+
+type bodyInfo struct {
+	named
+	massData *box2d.B2MassData
+}
+
+// NewLoader makes a Loader for the World.
+func NewLoader(w *box2d.B2World) *Loader {
+	return &Loader{
+		World:              w,
+		bodies:             make(map[*box2d.B2Body]*bodyInfo),
+		fixtures:           make(map[*box2d.B2Fixture]*named),
+		joints:             make(map[box2d.B2JointInterface]*named),
+		velocityIterations: 8,
+		positionIterations: 3,
+		stepsPerSecond:     60,
+	}
+}
+
+// Forget drops the editor data kept for a body that is being destroyed. Call
+// it before destroying the body.
+func (l *Loader) Forget(b *box2d.B2Body) {
+	delete(l.bodies, b)
+	for f := b.GetFixtureList(); f != nil; f = f.GetNext() {
+		delete(l.fixtures, f)
+	}
+	for je := b.GetJointList(); je != nil; je = je.Next {
+		delete(l.joints, je.Joint)
+	}
+}
+
+// Prune drops the editor data of anything that isn't in the World anymore,
+// such as bodies destroyed without calling Forget, or everything if the World
+// was replaced. Load and Save call it first.
+func (l *Loader) Prune() {
+	bodies := make(map[*box2d.B2Body]bool)
+	fixtures := make(map[*box2d.B2Fixture]bool)
+	for b := l.World.GetBodyList(); b != nil; b = b.GetNext() {
+		bodies[b] = true
+		for f := b.GetFixtureList(); f != nil; f = f.GetNext() {
+			fixtures[f] = true
+		}
+	}
+	joints := make(map[box2d.B2JointInterface]bool)
+	for j := l.World.GetJointList(); j != nil; j = j.GetNext() {
+		joints[j] = true
+	}
+
+	for b := range l.bodies {
+		if !bodies[b] {
+			delete(l.bodies, b)
+		}
+	}
+	for f := range l.fixtures {
+		if !fixtures[f] {
+			delete(l.fixtures, f)
+		}
+	}
+	for j := range l.joints {
+		if !joints[j] {
+			delete(l.joints, j)
+		}
+	}
+	images := l.images[:0]
+	for _, img := range l.images {
+		if img.Body == nil || bodies[img.Body] {
+			images = append(images, img)
+		}
+	}
+	l.images = images
+}
+
+// Load reads a RUBE json scene from r and adds its bodies and joints to the
+// World. World settings such as the gravity are applied as well.
+//
+// Values are used as they are in the file, unless FlipY is set.
+//
+// If there's an error, nothing in the scene is left in the World. Scenes are
+// best taken out with Unload; the editor data of bodies destroyed some other
+// way is only dropped the next time a scene is loaded or saved.
+func (l *Loader) Load(r io.Reader) (*Scene, error) {
+	var rw rubeWorld
+	if err := json.NewDecoder(r).Decode(&rw); err != nil {
+		return nil, err
+	}
+	if err := rw.validate(); err != nil {
+		return nil, err
+	}
+	if l.FlipY {
+		rw.flipY()
+	}
+	l.Prune()
+
+	scene := &Scene{
+		CustomProperties:   rw.CustomProperties,
+		VelocityIterations: rw.VelocityIterations,
+		PositionIterations: rw.PositionIterations,
+		StepsPerSecond:     rw.StepsPerSecond,
+	}
+
+	bodies := make([]*box2d.B2Body, 0, len(rw.Bodies))
+	// fail takes back out everything that was added before the error.
+	// Destroying the bodies destroys the joints too.
+	fail := func(err error) (*Scene, error) {
+		for _, b := range bodies {
+			l.Forget(b)
+			l.World.DestroyBody(b)
+		}
+		return nil, err
+	}
+
+	for i, rb := range rw.Bodies {
+		b, err := l.loadBody(rb)
+		if err != nil {
+			return fail(fmt.Errorf("body %d: %v", i, err))
+		}
+		scene.Bodies = append(scene.Bodies, b)
+		bodies = append(bodies, b.Body)
+	}
+
+	for i, rj := range rw.Joints {
+		j, err := l.loadJoint(rj, bodies)
+		if err != nil {
+			return fail(fmt.Errorf("joint %d: %v", i, err))
+		}
+		scene.Joints = append(scene.Joints, j)
+	}
+
+	for i, ri := range rw.Images {
+		img := &Image{
+			Name:             ri.Name,
+			File:             ri.File,
+			Center:           ri.Center.b2(),
+			Angle:            ri.Angle,
+			Scale:            ri.Scale,
+			AspectScale:      ri.AspectScale,
+			Flip:             ri.Flip,
+			Opacity:          ri.Opacity,
+			Filter:           ri.Filter,
+			RenderOrder:      ri.RenderOrder,
+			ColorTint:        ri.ColorTint,
+			CustomProperties: ri.CustomProperties,
+		}
+		if ri.Body >= 0 {
+			if ri.Body >= len(bodies) {
+				return fail(fmt.Errorf("image %d: body index %d out of range", i, ri.Body))
+			}
+			img.Body = bodies[ri.Body]
+		}
+		scene.Images = append(scene.Images, img)
+	}
+
+	l.World.SetGravity(rw.Gravity.b2())
+	l.World.SetAllowSleeping(rw.AllowSleep)
+	l.World.SetAutoClearForces(rw.AutoClearForces)
+	l.World.M_warmStarting = rw.WarmStarting
+	l.World.M_continuousPhysics = rw.ContinuousPhysics
+	l.World.M_subStepping = rw.SubStepping
+
+	l.images = append(l.images, scene.Images...)
+	l.props = rw.CustomProperties
+	l.velocityIterations = rw.VelocityIterations
+	l.positionIterations = rw.PositionIterations
+	l.stepsPerSecond = rw.StepsPerSecond
+
+	return scene, nil
+}
+
+// Unload destroys the bodies and joints of a scene loaded with Load, and drops
+// its images and editor data. Bodies that were already destroyed are skipped.
+// Like World.DestroyBody, don't call it during a time step.
+func (l *Loader) Unload(scene *Scene) {
+	l.Prune()
+	for _, b := range scene.Bodies {
+		if _, ok := l.bodies[b.Body]; ok {
+			l.Forget(b.Body)
+			l.World.DestroyBody(b.Body)
+		}
+	}
+
+	unloaded := make(map[*Image]bool, len(scene.Images))
+	for _, img := range scene.Images {
+		unloaded[img] = true
+	}
+	images := l.images[:0]
+	for _, img := range l.images {
+		if !unloaded[img] {
+			images = append(images, img)
+		}
+	}
+	l.images = images
+}
+
+func (l *Loader) loadBody(rb rubeBody) (*Body, error) {
+	// make the shapes first, so there's no body to clean up if one is bad
+	shapes := make([]box2d.B2ShapeInterface, len(rb.Fixtures))
+	for i, rf := range rb.Fixtures {
+		shape, err := rf.shape()
+		if err != nil {
+			return nil, fmt.Errorf("fixture %d: %v", i, err)
+		}
+		shapes[i] = shape
+	}
+
+	bodyDef := box2d.NewB2BodyDef()
+	bodyDef.Type = rb.Type
+	bodyDef.Position = rb.Position.b2()
+	bodyDef.Angle = rb.Angle
+	bodyDef.LinearVelocity = rb.LinearVelocity.b2()
+	bodyDef.AngularVelocity = rb.AngularVelocity
+	bodyDef.LinearDamping = rb.LinearDamping
+	bodyDef.AngularDamping = rb.AngularDamping
+	bodyDef.AllowSleep = rb.AllowSleep
+	bodyDef.Awake = rb.Awake
+	bodyDef.FixedRotation = rb.FixedRotation
+	bodyDef.Bullet = rb.Bullet
+	bodyDef.Active = rb.Active
+	bodyDef.GravityScale = rb.GravityScale
+	body := l.World.CreateBody(bodyDef)
+
+	info := &bodyInfo{named: named{rb.Name, rb.CustomProperties}}
+	l.bodies[body] = info
+
+	for i, rf := range rb.Fixtures {
+		f := body.CreateFixtureFromDef(&box2d.B2FixtureDef{
+			Shape:       shapes[i],
+			Density:     rf.Density,
+			Friction:    rf.Friction,
+			Restitution: rf.Restitution,
+			IsSensor:    rf.Sensor,
+			Filter: box2d.B2Filter{
+				CategoryBits: rf.CategoryBits,
+				MaskBits:     rf.MaskBits,
+				GroupIndex:   rf.GroupIndex,
+			},
+		})
+		if rf.Name != "" || len(rf.CustomProperties) > 0 {
+			l.fixtures[f] = &named{rf.Name, rf.CustomProperties}
+		}
+	}
+
+	if rb.Mass != nil {
+		info.massData = &box2d.B2MassData{
+			Mass:   *rb.Mass,
+			Center: rb.MassCenter.b2(),
+			I:      rb.MassI,
+		}
+		body.SetMassData(info.massData)
+	}
+
+	return &Body{Body: body, Name: rb.Name, CustomProperties: rb.CustomProperties}, nil
+}
+
+func (l *Loader) loadJoint(rj rubeJoint, bodies []*box2d.B2Body) (*Joint, error) {
+	bodyA, bodyB := bodies[rj.BodyA], bodies[rj.BodyB]
+
+	var def box2d.B2JointDefInterface
+	switch rj.Type {
+	case "revolute":
+		d := box2d.MakeB2RevoluteJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.ReferenceAngle = rj.RefAngle
+		d.EnableLimit = rj.EnableLimit
+		d.LowerAngle = rj.LowerLimit
+		d.UpperAngle = rj.UpperLimit
+		d.EnableMotor = rj.EnableMotor
+		d.MotorSpeed = rj.MotorSpeed
+		d.MaxMotorTorque = rj.MaxMotorTorque
+		def = &d
+	case "prismatic":
+		d := box2d.MakeB2PrismaticJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.LocalAxisA = rj.LocalAxisA.b2()
+		d.ReferenceAngle = rj.RefAngle
+		d.EnableLimit = rj.EnableLimit
+		d.LowerTranslation = rj.LowerLimit
+		d.UpperTranslation = rj.UpperLimit
+		d.EnableMotor = rj.EnableMotor
+		d.MotorSpeed = rj.MotorSpeed
+		d.MaxMotorForce = rj.MaxMotorForce
+		def = &d
+	case "distance":
+		d := box2d.MakeB2DistanceJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.Length = rj.Length
+		d.FrequencyHz = rj.Frequency
+		d.DampingRatio = rj.DampingRatio
+		def = &d
+	case "pulley":
+		d := box2d.MakeB2PulleyJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.GroundAnchorA = rj.GroundAnchorA.b2()
+		d.GroundAnchorB = rj.GroundAnchorB.b2()
+		d.LengthA = rj.LengthA
+		d.LengthB = rj.LengthB
+		d.Ratio = rj.Ratio
+		def = &d
+	case "wheel":
+		d := box2d.MakeB2WheelJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.LocalAxisA = rj.LocalAxisA.b2()
+		d.EnableMotor = rj.EnableMotor
+		d.MotorSpeed = rj.MotorSpeed
+		d.MaxMotorTorque = rj.MaxMotorTorque
+		d.FrequencyHz = rj.SpringFrequency
+		d.DampingRatio = rj.SpringDampingRatio
+		def = &d
+	case "weld":
+		d := box2d.MakeB2WeldJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.ReferenceAngle = rj.RefAngle
+		d.FrequencyHz = rj.Frequency
+		d.DampingRatio = rj.DampingRatio
+		def = &d
+	case "friction":
+		d := box2d.MakeB2FrictionJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.MaxForce = rj.MaxForce
+		d.MaxTorque = rj.MaxTorque
+		def = &d
+	case "rope":
+		d := box2d.MakeB2RopeJointDef()
+		d.LocalAnchorA = rj.AnchorA.b2()
+		d.LocalAnchorB = rj.AnchorB.b2()
+		d.MaxLength = rj.MaxLength
+		def = &d
+	case "motor":
+		d := box2d.MakeB2MotorJointDef()
+		d.LinearOffset = rj.LinearOffset.b2()
+		d.AngularOffset = rj.RefAngle
+		d.MaxForce = rj.MaxForce
+		d.MaxTorque = rj.MaxTorque
+		d.CorrectionFactor = rj.CorrectionFactor
+		def = &d
+	case "mouse":
+		d := box2d.MakeB2MouseJointDef()
+		d.Target = rj.Target.b2()
+		d.MaxForce = rj.MaxForce
+		d.FrequencyHz = rj.Frequency
+		d.DampingRatio = rj.DampingRatio
+		def = &d
+	default:
+		return nil, fmt.Errorf("unsupported joint type %q", rj.Type)
+	}
+	def.SetBodyA(bodyA)
+	def.SetBodyB(bodyB)
+	def.SetCollideConnected(rj.CollideConnected)
+
+	j := l.World.CreateJoint(def)
+	l.joints[j] = &named{rj.Name, rj.CustomProperties}
+	return &Joint{Joint: j, Name: rj.Name, CustomProperties: rj.CustomProperties}, nil
+}
+
+// Save writes every body and joint in the World to w as a RUBE json scene.
+// Names, custom properties and images loaded with Load are written back out.
+// Bodies, fixtures and joints are written in creation order, so loading the
+// result recreates the same l.World.
+func (l *Loader) Save(w io.Writer) error {
+	l.Prune()
+	rw := rubeWorld{
+		Gravity:            vec2(l.World.GetGravity()),
+		AllowSleep:         l.World.M_allowSleep,
+		AutoClearForces:    l.World.GetAutoClearForces(),
+		WarmStarting:       l.World.M_warmStarting,
+		ContinuousPhysics:  l.World.M_continuousPhysics,
+		SubStepping:        l.World.M_subStepping,
+		VelocityIterations: l.velocityIterations,
+		PositionIterations: l.positionIterations,
+		StepsPerSecond:     l.stepsPerSecond,
+		CustomProperties:   l.props,
+	}
+
+	// box2d keeps its lists newest first
+	var bodies []*box2d.B2Body
+	for b := l.World.GetBodyList(); b != nil; b = b.GetNext() {
+		bodies = append([]*box2d.B2Body{b}, bodies...)
+	}
+	index := make(map[*box2d.B2Body]int, len(bodies))
+	for i, b := range bodies {
+		index[b] = i
+		rw.Bodies = append(rw.Bodies, l.saveBody(b))
+	}
+
+	var joints []box2d.B2JointInterface
+	for j := l.World.GetJointList(); j != nil; j = j.GetNext() {
+		joints = append([]box2d.B2JointInterface{j}, joints...)
+	}
+	for _, j := range joints {
+		rj, err := l.saveJoint(j, index)
+		if err != nil {
+			return err
+		}
+		rw.Joints = append(rw.Joints, rj)
+	}
+
+	for _, img := range l.images {
+		bodyIndex := -1
+		if img.Body != nil {
+			i, ok := index[img.Body]
+			if !ok {
+				continue // the body was destroyed
+			}
+			bodyIndex = i
+		}
+		rw.Images = append(rw.Images, rubeImage{
+			Name:             img.Name,
+			File:             img.File,
+			Body:             bodyIndex,
+			Center:           vec2(img.Center),
+			Angle:            img.Angle,
+			Scale:            img.Scale,
+			AspectScale:      img.AspectScale,
+			Flip:             img.Flip,
+			Opacity:          img.Opacity,
+			Filter:           img.Filter,
+			RenderOrder:      img.RenderOrder,
+			ColorTint:        img.ColorTint,
+			CustomProperties: img.CustomProperties,
+		})
+	}
+
+	if l.FlipY {
+		rw.flipY()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rw)
+}
+
+func (l *Loader) saveBody(b *box2d.B2Body) rubeBody {
+	rb := rubeBody{
+		Type:            b.GetType(),
+		Position:        vec2(b.GetPosition()),
+		Angle:           b.GetAngle(),
+		LinearVelocity:  vec2(b.GetLinearVelocity()),
+		AngularVelocity: b.GetAngularVelocity(),
+		LinearDamping:   b.GetLinearDamping(),
+		AngularDamping:  b.GetAngularDamping(),
+		AllowSleep:      b.IsSleepingAllowed(),
+		Awake:           b.IsAwake(),
+		FixedRotation:   b.IsFixedRotation(),
+		Bullet:          b.IsBullet(),
+		Active:          b.IsActive(),
+		GravityScale:    b.GetGravityScale(),
+	}
+	if info, ok := l.bodies[b]; ok {
+		rb.Name = info.name
+		rb.CustomProperties = info.props
+		if info.massData != nil {
+			rb.Mass = &info.massData.Mass
+			rb.MassCenter = vec2(info.massData.Center)
+			rb.MassI = info.massData.I
+		}
+	}
+
+	for f := b.GetFixtureList(); f != nil; f = f.GetNext() {
+		filter := f.GetFilterData()
+		rf := rubeFixture{
+			Density:      f.GetDensity(),
+			Friction:     f.GetFriction(),
+			Restitution:  f.GetRestitution(),
+			Sensor:       f.IsSensor(),
+			CategoryBits: filter.CategoryBits,
+			MaskBits:     filter.MaskBits,
+			GroupIndex:   filter.GroupIndex,
+		}
+		if info, ok := l.fixtures[f]; ok {
+			rf.Name = info.name
+			rf.CustomProperties = info.props
+		}
+		switch s := f.GetShape().(type) {
+		case *box2d.B2CircleShape:
+			rf.Circle = &rubeCircle{Center: vec2(s.M_p), Radius: s.M_radius}
+		case *box2d.B2PolygonShape:
+			rf.Polygon = &rubeVertices{Vertices: verticesOf(s.M_vertices[:s.M_count])}
+		case *box2d.B2EdgeShape:
+			rf.Chain = &rubeChain{
+				Vertices:      verticesOf([]box2d.B2Vec2{s.M_vertex1, s.M_vertex2}),
+				HasPrevVertex: s.M_hasVertex0,
+				HasNextVertex: s.M_hasVertex3,
+			}
+			if s.M_hasVertex0 {
+				rf.Chain.PrevVertex = vec2Ptr(s.M_vertex0)
+			}
+			if s.M_hasVertex3 {
+				rf.Chain.NextVertex = vec2Ptr(s.M_vertex3)
+			}
+		case *box2d.B2ChainShape:
+			rf.Chain = &rubeChain{
+				Vertices:      verticesOf(s.M_vertices[:s.M_count]),
+				HasPrevVertex: s.M_hasPrevVertex,
+				HasNextVertex: s.M_hasNextVertex,
+			}
+			if s.M_hasPrevVertex {
+				rf.Chain.PrevVertex = vec2Ptr(s.M_prevVertex)
+			}
+			if s.M_hasNextVertex {
+				rf.Chain.NextVertex = vec2Ptr(s.M_nextVertex)
+			}
+		}
+		rb.Fixtures = append([]rubeFixture{rf}, rb.Fixtures...)
+	}
+	return rb
+}
+
+func (l *Loader) saveJoint(j box2d.B2JointInterface, index map[*box2d.B2Body]int) (rubeJoint, error) {
+	rj := rubeJoint{
+		BodyA:            index[j.GetBodyA()],
+		BodyB:            index[j.GetBodyB()],
+		CollideConnected: j.IsCollideConnected(),
+	}
+	if info, ok := l.joints[j]; ok {
+		rj.Name = info.name
+		rj.CustomProperties = info.props
+	}
+
+	switch t := j.(type) {
+	case *box2d.B2RevoluteJoint:
+		rj.Type = "revolute"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.RefAngle = t.M_referenceAngle
+		rj.EnableLimit = t.M_enableLimit
+		rj.LowerLimit, rj.UpperLimit = t.M_lowerAngle, t.M_upperAngle
+		rj.EnableMotor = t.M_enableMotor
+		rj.MotorSpeed = t.M_motorSpeed
+		rj.MaxMotorTorque = t.M_maxMotorTorque
+	case *box2d.B2PrismaticJoint:
+		rj.Type = "prismatic"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.LocalAxisA = vec2(t.M_localXAxisA)
+		rj.RefAngle = t.M_referenceAngle
+		rj.EnableLimit = t.M_enableLimit
+		rj.LowerLimit, rj.UpperLimit = t.M_lowerTranslation, t.M_upperTranslation
+		rj.EnableMotor = t.M_enableMotor
+		rj.MotorSpeed = t.M_motorSpeed
+		rj.MaxMotorForce = t.M_maxMotorForce
+	case *box2d.B2DistanceJoint:
+		rj.Type = "distance"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.Length = t.M_length
+		rj.Frequency = t.M_frequencyHz
+		rj.DampingRatio = t.M_dampingRatio
+	case *box2d.B2PulleyJoint:
+		rj.Type = "pulley"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.GroundAnchorA, rj.GroundAnchorB = vec2(t.M_groundAnchorA), vec2(t.M_groundAnchorB)
+		rj.LengthA, rj.LengthB = t.M_lengthA, t.M_lengthB
+		rj.Ratio = t.M_ratio
+	case *box2d.B2WheelJoint:
+		rj.Type = "wheel"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.LocalAxisA = vec2(t.M_localXAxisA)
+		rj.EnableMotor = t.M_enableMotor
+		rj.MotorSpeed = t.M_motorSpeed
+		rj.MaxMotorTorque = t.M_maxMotorTorque
+		rj.SpringFrequency = t.M_frequencyHz
+		rj.SpringDampingRatio = t.M_dampingRatio
+	case *box2d.B2WeldJoint:
+		rj.Type = "weld"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.RefAngle = t.M_referenceAngle
+		rj.Frequency = t.M_frequencyHz
+		rj.DampingRatio = t.M_dampingRatio
+	case *box2d.B2FrictionJoint:
+		rj.Type = "friction"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.MaxForce = t.M_maxForce
+		rj.MaxTorque = t.M_maxTorque
+	case *box2d.B2RopeJoint:
+		rj.Type = "rope"
+		rj.AnchorA, rj.AnchorB = vec2(t.M_localAnchorA), vec2(t.M_localAnchorB)
+		rj.MaxLength = t.M_maxLength
+	case *box2d.B2MotorJoint:
+		rj.Type = "motor"
+		rj.LinearOffset = vec2(t.M_linearOffset)
+		rj.RefAngle = t.M_angularOffset
+		rj.MaxForce = t.M_maxForce
+		rj.MaxTorque = t.M_maxTorque
+		rj.CorrectionFactor = t.M_correctionFactor
+	case *box2d.B2MouseJoint:
+		rj.Type = "mouse"
+		rj.Target = vec2(t.M_targetA)
+		rj.MaxForce = t.M_maxForce
+		rj.Frequency = t.M_frequencyHz
+		rj.DampingRatio = t.M_dampingRatio
+	default:
+		return rj, fmt.Errorf("unsupported joint type %T", j)
+	}
+	return rj, nil
+}
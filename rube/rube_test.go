@@ -0,0 +1,198 @@
+package rube
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ByteArena/box2d"
+)
+
+const testScene = `{
+	"gravity": {"x": 0, "y": -10},
+	"body": [
+		{"name": "ground", "type": 0, "position": 0, "fixture": [
+			{"name": "top", "polygon": {"vertices": {"x": [-5, 5, 5, -5], "y": [-1, -1, 0, 0]}}}
+		]},
+		{"name": "wheel", "type": 2, "position": {"x": 0, "y": 2}, "fixture": [
+			{"density": 1, "circle": {"center": 0, "radius": 0.5}}
+		]}
+	],
+	"joint": [
+		{"type": "revolute", "name": "axle", "bodyA": 0, "bodyB": 1, "anchorA": {"x": 0, "y": 2}, "anchorB": 0}
+	],
+	"image": [
+		{"file": "wheel.png", "body": 1, "center": 0},
+		{"file": "sky.png", "center": 0}
+	]
+}`
+
+func newTestLoader() *Loader {
+	w := box2d.MakeB2World(box2d.B2Vec2{})
+	return NewLoader(&w)
+}
+
+func TestLoaderRoundTrip(t *testing.T) {
+	l := newTestLoader()
+	scene, err := l.Load(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if len(scene.Bodies) != 2 || scene.Bodies[1].Name != "wheel" || len(scene.Joints) != 1 || len(scene.Images) != 2 {
+		t.Fatalf("scene was not loaded, got: %+v", scene)
+	}
+	if g := l.World.GetGravity(); g.Y != -10 {
+		t.Errorf("gravity was not set, got: %v", g)
+	}
+
+	var saved bytes.Buffer
+	if err = l.Save(&saved); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	again := newTestLoader()
+	reloaded, err := again.Load(&saved)
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if reloaded.Bodies[0].Name != "ground" || reloaded.Joints[0].Name != "axle" || len(reloaded.Images) != 2 {
+		t.Errorf("editor data was not saved, got: %+v", reloaded)
+	}
+	if _, ok := again.fixtures[reloaded.Bodies[0].Body.GetFixtureList()]; !ok {
+		t.Errorf("fixture name was not saved")
+	}
+}
+
+func TestLoaderFlipY(t *testing.T) {
+	const scene = `{
+		"gravity": {"x": 0, "y": -10},
+		"body": [
+			{"type": 0, "position": 0, "fixture": [
+				{"polygon": {"vertices": {"x": [0, 2, 0], "y": [0, 0, 1]}}}
+			]},
+			{"type": 2, "position": {"x": 1, "y": 2}, "angle": 0.5, "angularVelocity": 1, "fixture": [
+				{"density": 1, "circle": {"center": {"x": 0, "y": 0.25}, "radius": 0.5}}
+			]}
+		],
+		"joint": [
+			{"type": "revolute", "bodyA": 0, "bodyB": 1, "anchorA": {"x": 1, "y": 2}, "anchorB": 0,
+				"enableLimit": true, "lowerLimit": -0.25, "upperLimit": 1, "motorSpeed": 2}
+		]
+	}`
+	l := newTestLoader()
+	l.FlipY = true
+	loaded, err := l.Load(strings.NewReader(scene))
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	if g := l.World.GetGravity(); g.Y != 10 {
+		t.Errorf("gravity was not flipped, got: %v", g)
+	}
+	body := loaded.Bodies[1].Body
+	if p := body.GetPosition(); p != (box2d.B2Vec2{X: 1, Y: -2}) {
+		t.Errorf("position was not flipped, got: %v", p)
+	}
+	if body.GetAngle() != -0.5 || body.GetAngularVelocity() != -1 {
+		t.Errorf("angles were not negated, got: %v %v", body.GetAngle(), body.GetAngularVelocity())
+	}
+	if c := body.GetFixtureList().GetShape().(*box2d.B2CircleShape).M_p; c.Y != -0.25 {
+		t.Errorf("circle center was not flipped, got: %v", c)
+	}
+	poly := loaded.Bodies[0].Body.GetFixtureList().GetShape().(*box2d.B2PolygonShape)
+	if !poly.TestPoint(loaded.Bodies[0].Body.GetTransform(), box2d.B2Vec2{X: 0.5, Y: -0.25}) {
+		t.Errorf("polygon was not flipped, got: %v", poly.M_vertices[:poly.M_count])
+	}
+	joint := loaded.Joints[0].Joint.(*box2d.B2RevoluteJoint)
+	if joint.M_lowerAngle != -1 || joint.M_upperAngle != 0.25 || joint.M_motorSpeed != -2 {
+		t.Errorf("joint limits and speed were not flipped, got: %v %v %v", joint.M_lowerAngle, joint.M_upperAngle, joint.M_motorSpeed)
+	}
+
+	var saved bytes.Buffer
+	if err = l.Save(&saved); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	var rw rubeWorld
+	if err = json.Unmarshal(saved.Bytes(), &rw); err != nil {
+		t.Fatalf("saved scene is not json: %v", err)
+	}
+	if rw.Gravity.Y != -10 || rw.Bodies[1].Position != (rubeVec2{1, 2}) || rw.Bodies[1].Angle != 0.5 {
+		t.Errorf("scene was not flipped back when saved, got: %+v", rw)
+	}
+	if rj := rw.Joints[0]; rj.LowerLimit != -0.25 || rj.UpperLimit != 1 || rj.MotorSpeed != 2 || rj.AnchorA != (rubeVec2{1, 2}) {
+		t.Errorf("joint was not flipped back when saved, got: %+v", rj)
+	}
+	// RUBE wants polygons wound counter clockwise, which mirroring undoes
+	vs := rw.Bodies[0].Fixtures[0].Polygon.Vertices
+	var area float64
+	for i := range vs.X {
+		j := (i + 1) % len(vs.X)
+		area += vs.X[i]*vs.Y[j] - vs.X[j]*vs.Y[i]
+	}
+	if area <= 0 {
+		t.Errorf("saved polygon is wound clockwise, got: %+v", vs)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	bad := []string{
+		`{"gravity": {"x": 0, "y": -10}, "body": [{"type": 2}, {"type": 0}], "joint": [{"type": "weld", "bodyA": 0, "bodyB": 1}, {"type": "weld", "bodyA": 0, "bodyB": 4}]}`,
+		`{"body": [{"type": 2}, {"type": 2, "fixture": [{}]}]}`,
+		`{"body": [{"type": 2}], "image": [{"file": "a.png", "body": 3}]}`,
+		// collinear polygon
+		`{"body": [{"type": 2}, {"type": 2, "fixture": [{"polygon": {"vertices": {"x": [0, 1, 2], "y": [0, 0, 0]}}}]}]}`,
+		// polygon with its points welded together
+		`{"body": [{"type": 2}, {"type": 2, "fixture": [{"polygon": {"vertices": {"x": [0, 0.001, 0], "y": [0, 0, 0.001]}}}]}]}`,
+		// chain and edge with vertices too close
+		`{"body": [{"type": 2}, {"type": 0, "fixture": [{"chain": {"vertices": {"x": [0, 1, 1.001], "y": [0, 0, 0]}}}]}]}`,
+		`{"body": [{"type": 2}, {"type": 0, "fixture": [{"chain": {"vertices": {"x": [0, 0], "y": [0, 0.001]}}}]}]}`,
+		`{"body": [{"type": 2}, {"type": 2, "fixture": [{"circle": {"center": 0, "radius": 0}}]}]}`,
+		`{"body": [{"type": 2}, {"type": 3}]}`,
+		`{"body": [{"type": 2}, {"type": 2}], "joint": [{"type": "weld", "bodyA": 1, "bodyB": 1}]}`,
+	}
+	for i, s := range bad {
+		l := newTestLoader()
+		if _, err := l.Load(strings.NewReader(s)); err == nil {
+			t.Errorf("Load did not return an error for bad scene %d", i)
+		}
+		if l.World.GetBodyCount() != 0 || l.World.GetJointCount() != 0 {
+			t.Errorf("bad scene %d left bodies or joints in the World", i)
+		}
+		if len(l.bodies) != 0 || len(l.fixtures) != 0 || len(l.joints) != 0 || len(l.images) != 0 {
+			t.Errorf("bad scene %d left editor data behind", i)
+		}
+		if g := l.World.GetGravity(); g.Y != 0 {
+			t.Errorf("bad scene %d changed the gravity to %v", i, g)
+		}
+	}
+}
+
+func TestLoaderUnloadAndPrune(t *testing.T) {
+	l := newTestLoader()
+	first, err := l.Load(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	second, err := l.Load(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	// destroyed without Forget
+	l.World.DestroyBody(first.Bodies[1].Body)
+	l.Unload(first)
+	if l.World.GetBodyCount() != 2 || l.World.GetJointCount() != 1 {
+		t.Errorf("wrong bodies left, want 2 bodies and 1 joint, got: %d and %d", l.World.GetBodyCount(), l.World.GetJointCount())
+	}
+	if len(l.bodies) != 2 || len(l.fixtures) != 1 || len(l.joints) != 1 || len(l.images) != 2 {
+		t.Errorf("editor data of the unloaded scene was kept, have %d bodies, %d fixtures, %d joints, %d images",
+			len(l.bodies), len(l.fixtures), len(l.joints), len(l.images))
+	}
+
+	l.World.DestroyBody(second.Bodies[0].Body)
+	l.Prune()
+	if len(l.bodies) != 1 || len(l.fixtures) != 0 || len(l.joints) != 0 || len(l.images) != 2 {
+		t.Errorf("editor data of a destroyed body was kept, have %d bodies, %d fixtures, %d joints, %d images",
+			len(l.bodies), len(l.fixtures), len(l.joints), len(l.images))
+	}
+}
@@ -0,0 +1,158 @@
+package engoBox2dSystem
+
+import (
+	"io"
+	stdmath "math"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+
+	"github.com/Noofbiz/engoBox2dSystem/rube"
+)
+
+// Scene is the result of loading a RUBE scene with LoadScene.
+type Scene struct {
+	// Entities are the bodies of the scene, in the order they appear in the file.
+	Entities []*SceneEntity
+	// Joints are the joints of the scene, in the order they appear in the file.
+	Joints []*SceneJoint
+	// Images are the images of the scene. Images that are attached to a body
+	// have their Body set.
+	Images []*SceneImage
+	// CustomProperties are the properties set on the scene itself.
+	CustomProperties []CustomProperty
+
+	// VelocityIterations, PositionIterations, and StepsPerSecond are the
+	// simulation settings stored with the scene. They are not applied to any
+	// system; use them when setting up the PhysicsSystem.
+	VelocityIterations, PositionIterations, StepsPerSecond int
+}
+
+// SceneEntity is a body loaded from a scene. It implements the Physicsable and
+// Collisionable interfaces, so it can be added directly to those systems.
+type SceneEntity struct {
+	ecs.BasicEntity
+	common.SpaceComponent
+	Box2dComponent
+
+	// Name is the name given to the body in the editor.
+	Name string
+	// CustomProperties are the properties set on the body in the editor.
+	CustomProperties []CustomProperty
+}
+
+// SceneJoint is a joint loaded from a scene.
+type SceneJoint = rube.Joint
+
+// SceneImage is an image placed in the editor. Positions and sizes are in
+// box2d units; if Body is set they are relative to the body.
+type SceneImage = rube.Image
+
+// CustomProperty is a named value set in the editor. Exactly one of the values
+// is set.
+type CustomProperty = rube.CustomProperty
+
+// sceneData loads the scenes into World, and holds the editor data that box2d
+// has no place for, so SaveScene can write it back out.
+var sceneData = newSceneLoader()
+
+// newSceneLoader makes a Loader for World that flips the editor's y axis into
+// engo's.
+func newSceneLoader() *rube.Loader {
+	l := rube.NewLoader(&World)
+	l.FlipY = true
+	return l
+}
+
+// LoadScene reads a RUBE json scene from r and adds its bodies and joints to
+// World. World settings such as the gravity are applied as well. Each body gets
+// a new entity with a SpaceComponent centered on the body's origin and sized to
+// fit its fixtures, converted with Conv. The loading itself is done by the rube
+// package, which can be used without engo.
+//
+// The editor's y axis points up while engo's points down, so the scene is
+// mirrored as it's loaded: y values and angles are negated, which also keeps
+// the gravity pointing down. SaveScene mirrors it back.
+//
+// If there's an error, nothing in the scene is left in World. Scenes are best
+// taken out with UnloadScene; the editor data of bodies destroyed with
+// World.DestroyBody is only dropped the next time a scene is loaded or saved.
+func LoadScene(r io.Reader) (*Scene, error) {
+	rs, err := sceneData.Load(r)
+	if err != nil {
+		return nil, err
+	}
+	scene := &Scene{
+		Joints:             rs.Joints,
+		Images:             rs.Images,
+		CustomProperties:   rs.CustomProperties,
+		VelocityIterations: rs.VelocityIterations,
+		PositionIterations: rs.PositionIterations,
+		StepsPerSecond:     rs.StepsPerSecond,
+	}
+	for _, b := range rs.Bodies {
+		scene.Entities = append(scene.Entities, &SceneEntity{
+			BasicEntity:      ecs.NewBasic(),
+			SpaceComponent:   sceneSpace(b.Body),
+			Box2dComponent:   Box2dComponent{Body: b.Body},
+			Name:             b.Name,
+			CustomProperties: b.CustomProperties,
+		})
+	}
+	return scene, nil
+}
+
+// UnloadScene destroys the bodies and joints of a scene loaded with LoadScene,
+// and drops its images and editor data. Bodies that were already destroyed are
+// skipped. Like World.DestroyBody, don't call it during a time step.
+func UnloadScene(scene *Scene) {
+	rs := &rube.Scene{Joints: scene.Joints, Images: scene.Images}
+	for _, e := range scene.Entities {
+		rs.Bodies = append(rs.Bodies, &rube.Body{Body: e.Body, Name: e.Name})
+	}
+	sceneData.Unload(rs)
+}
+
+// SaveScene writes every body and joint in World to w as a RUBE json scene.
+// Names, custom properties and images loaded with LoadScene are written back
+// out. Bodies, fixtures and joints are written in creation order, so loading
+// the result recreates the same World, mirrored back into the editor's y axis.
+func SaveScene(w io.Writer) error {
+	return sceneData.Save(w)
+}
+
+// sceneSpace returns a SpaceComponent centered on the body's origin that is
+// big enough to hold all of its fixtures.
+func sceneSpace(body *box2d.B2Body) common.SpaceComponent {
+	var halfW, halfH float64
+	extend := func(v box2d.B2Vec2, r float64) {
+		halfW = stdmath.Max(halfW, stdmath.Abs(v.X)+r)
+		halfH = stdmath.Max(halfH, stdmath.Abs(v.Y)+r)
+	}
+	for f := body.GetFixtureList(); f != nil; f = f.GetNext() {
+		switch s := f.GetShape().(type) {
+		case *box2d.B2CircleShape:
+			extend(s.M_p, s.M_radius)
+		case *box2d.B2PolygonShape:
+			for _, v := range s.M_vertices[:s.M_count] {
+				extend(v, 0)
+			}
+		case *box2d.B2EdgeShape:
+			extend(s.M_vertex1, 0)
+			extend(s.M_vertex2, 0)
+		case *box2d.B2ChainShape:
+			for _, v := range s.M_vertices[:s.M_count] {
+				extend(v, 0)
+			}
+		}
+	}
+	space := common.SpaceComponent{
+		Width:    Conv.MetersToPx(halfW * 2),
+		Height:   Conv.MetersToPx(halfH * 2),
+		Rotation: Conv.RadToDeg(body.GetAngle()),
+	}
+	space.SetCenter(Conv.ToEngoPoint(body.GetPosition()))
+	return space
+}
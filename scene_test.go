@@ -0,0 +1,355 @@
+package engoBox2dSystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+const testScene = `{
+	"gravity": {"x": 0, "y": -10},
+	"allowSleep": true,
+	"autoClearForces": true,
+	"positionIterations": 3,
+	"velocityIterations": 8,
+	"stepsPerSecond": 60,
+	"warmStarting": true,
+	"continuousPhysics": true,
+	"subStepping": false,
+	"customProperties": [{"name": "level", "int": 3}],
+	"body": [
+		{
+			"name": "ground",
+			"type": 0,
+			"position": 0,
+			"fixture": [
+				{
+					"name": "floor",
+					"friction": 0.8,
+					"chain": {"vertices": {"x": [-20, 0, 20], "y": [10, 12, 10]}}
+				},
+				{
+					"friction": 0.2,
+					"chain": {"vertices": {"x": [-20, -20], "y": [0, 10]}}
+				}
+			]
+		},
+		{
+			"name": "crate",
+			"type": 2,
+			"position": {"x": 1, "y": 2},
+			"angle": 0.5,
+			"awake": true,
+			"linearVelocity": {"x": 1.5, "y": 0},
+			"fixture": [
+				{
+					"density": 1,
+					"friction": 0.3,
+					"restitution": 0.1,
+					"filter-categoryBits": 2,
+					"filter-maskBits": 5,
+					"polygon": {"vertices": {"x": [-1, 1, 1, -1], "y": [-0.5, -0.5, 0.5, 0.5]}}
+				}
+			],
+			"customProperties": [
+				{"name": "health", "float": 12.5},
+				{"name": "kind", "string": "wood"},
+				{"name": "breakable", "bool": true},
+				{"name": "spawn", "vec2": {"x": 4, "y": 5}},
+				{"name": "tint", "color": [255, 0, 0, 255]}
+			]
+		},
+		{
+			"name": "wheel",
+			"type": 2,
+			"position": {"x": 3, "y": 2},
+			"awake": true,
+			"bullet": true,
+			"massData-mass": 4,
+			"massData-center": 0,
+			"massData-I": 2,
+			"fixture": [
+				{"density": 2, "sensor": true, "circle": {"center": 0, "radius": 0.75}}
+			]
+		},
+		{
+			"name": "platform",
+			"type": 1,
+			"position": {"x": -5, "y": 4},
+			"fixture": [
+				{"polygon": {"vertices": {"x": [-2, 2, 2, -2], "y": [-0.25, -0.25, 0.25, 0.25]}}}
+			]
+		}
+	],
+	"joint": [
+		{"type": "revolute", "name": "axle", "bodyA": 1, "bodyB": 2, "anchorA": {"x": 2, "y": 0}, "anchorB": 0, "enableMotor": true, "motorSpeed": 2, "maxMotorTorque": 10},
+		{"type": "distance", "bodyA": 0, "bodyB": 1, "anchorA": {"x": 1, "y": 0}, "anchorB": 0, "length": 3, "frequency": 4, "dampingRatio": 0.5},
+		{"type": "prismatic", "bodyA": 0, "bodyB": 3, "anchorA": {"x": -5, "y": 4}, "anchorB": 0, "localAxisA": {"x": 1, "y": 0}, "enableLimit": true, "lowerLimit": -2, "upperLimit": 2},
+		{"type": "weld", "bodyA": 1, "bodyB": 3, "anchorA": 0, "anchorB": 0, "refAngle": 0.25},
+		{"type": "rope", "bodyA": 0, "bodyB": 2, "anchorA": 0, "anchorB": 0, "maxLength": 6},
+		{"type": "wheel", "bodyA": 3, "bodyB": 2, "anchorA": 0, "anchorB": 0, "localAxisA": {"x": 0, "y": 1}, "springFrequency": 4, "springDampingRatio": 0.7},
+		{"type": "friction", "bodyA": 0, "bodyB": 1, "anchorA": 0, "anchorB": 0, "maxForce": 5, "maxTorque": 1},
+		{"type": "motor", "bodyA": 0, "bodyB": 3, "linearOffset": {"x": 1, "y": 1}, "maxForce": 5, "maxTorque": 1, "correctionFactor": 0.3},
+		{"type": "pulley", "bodyA": 1, "bodyB": 2, "anchorA": 0, "anchorB": 0, "groundAnchorA": {"x": 1, "y": -2}, "groundAnchorB": {"x": 3, "y": -2}, "lengthA": 4, "lengthB": 4, "ratio": 1}
+	],
+	"image": [
+		{"name": "crate", "file": "crate.png", "body": 1, "center": 0, "scale": 2},
+		{"name": "sky", "file": "sky.png", "center": {"x": 0, "y": -10}, "renderOrder": -1}
+	]
+}`
+
+func TestLoadScene(t *testing.T) {
+	newTestWorld(t, 0)
+
+	scene, err := LoadScene(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("LoadScene returned an error: %v", err)
+	}
+
+	if len(scene.Entities) != 4 {
+		t.Fatalf("wrong number of entities, want: %d, got: %d", 4, len(scene.Entities))
+	}
+	if len(scene.Joints) != 9 {
+		t.Errorf("wrong number of joints, want: %d, got: %d", 9, len(scene.Joints))
+	}
+	if len(scene.Images) != 2 {
+		t.Errorf("wrong number of images, want: %d, got: %d", 2, len(scene.Images))
+	}
+	if World.GetBodyCount() != 4 || World.GetJointCount() != 9 {
+		t.Errorf("world has wrong counts, bodies: %d, joints: %d", World.GetBodyCount(), World.GetJointCount())
+	}
+	if World.GetGravity() != (box2d.B2Vec2{X: 0, Y: 10}) {
+		t.Errorf("gravity was not applied pointing down, got: %v", World.GetGravity())
+	}
+	if scene.VelocityIterations != 8 || scene.PositionIterations != 3 || scene.StepsPerSecond != 60 {
+		t.Errorf("wrong simulation settings, got: %d %d %d", scene.VelocityIterations, scene.PositionIterations, scene.StepsPerSecond)
+	}
+	if len(scene.CustomProperties) != 1 || *scene.CustomProperties[0].Int != 3 {
+		t.Errorf("scene custom properties not loaded, got: %v", scene.CustomProperties)
+	}
+
+	crate := scene.Entities[1]
+	if crate.Name != "crate" {
+		t.Errorf("wrong entity name, want: %s, got: %s", "crate", crate.Name)
+	}
+	if crate.Body.GetType() != box2d.B2BodyType.B2_dynamicBody {
+		t.Errorf("crate is not dynamic")
+	}
+	if center := crate.SpaceComponent.Center(); center != Conv.ToEngoPoint(box2d.B2Vec2{X: 1, Y: -2}) {
+		t.Errorf("space component not centered on the body, got: %v", center)
+	}
+	if crate.SpaceComponent.Width != Conv.MetersToPx(2) || crate.SpaceComponent.Height != Conv.MetersToPx(1) {
+		t.Errorf("space component has wrong size, got: %v x %v", crate.SpaceComponent.Width, crate.SpaceComponent.Height)
+	}
+	if crate.SpaceComponent.Rotation != Conv.RadToDeg(-0.5) {
+		t.Errorf("space component has wrong rotation, got: %v", crate.SpaceComponent.Rotation)
+	}
+	filter := crate.Body.GetFixtureList().GetFilterData()
+	if filter.CategoryBits != 2 || filter.MaskBits != 5 {
+		t.Errorf("fixture filter not loaded, got: %+v", filter)
+	}
+	props := crate.CustomProperties
+	if len(props) != 5 || *props[0].Float != 12.5 || *props[1].String != "wood" || !*props[2].Bool ||
+		*props[3].Vec2 != (box2d.B2Vec2{X: 4, Y: 5}) || len(props[4].Color) != 4 {
+		t.Errorf("body custom properties not loaded, got: %+v", props)
+	}
+
+	wheel := scene.Entities[2].Body
+	if wheel.GetMass() != 4 {
+		t.Errorf("mass data override not applied, want: %v, got: %v", 4, wheel.GetMass())
+	}
+	if !wheel.IsBullet() || !wheel.GetFixtureList().IsSensor() {
+		t.Errorf("wheel flags not loaded")
+	}
+
+	ground := scene.Entities[0].Body
+	var chains, edges int
+	for f := ground.GetFixtureList(); f != nil; f = f.GetNext() {
+		switch f.GetShape().(type) {
+		case *box2d.B2ChainShape:
+			chains++
+		case *box2d.B2EdgeShape:
+			edges++
+		}
+	}
+	if chains != 1 || edges != 1 {
+		t.Errorf("ground fixtures not loaded, chains: %d, edges: %d", chains, edges)
+	}
+	// the ground spans x from -20 to 20 and y from 0 to 12
+	if scene.Entities[0].SpaceComponent.Width != Conv.MetersToPx(40) || scene.Entities[0].SpaceComponent.Height != Conv.MetersToPx(24) {
+		t.Errorf("ground space component has wrong size, got: %v x %v", scene.Entities[0].SpaceComponent.Width, scene.Entities[0].SpaceComponent.Height)
+	}
+
+	if _, ok := scene.Joints[0].Joint.(*box2d.B2RevoluteJoint); !ok || scene.Joints[0].Name != "axle" {
+		t.Errorf("first joint should be the revolute joint named axle, got: %T %q", scene.Joints[0].Joint, scene.Joints[0].Name)
+	}
+	if scene.Images[0].Body != crate.Body || scene.Images[1].Body != nil {
+		t.Errorf("images attached to the wrong bodies")
+	}
+	if scene.Images[1].Scale != 1 || scene.Images[1].Opacity != 1 {
+		t.Errorf("image defaults not applied, got: %+v", scene.Images[1])
+	}
+}
+
+func TestSceneRoundTrip(t *testing.T) {
+	newTestWorld(t, 0)
+
+	first, err := LoadScene(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("LoadScene returned an error: %v", err)
+	}
+	var saved bytes.Buffer
+	if err = SaveScene(&saved); err != nil {
+		t.Fatalf("SaveScene returned an error: %v", err)
+	}
+	firstPoints := make([]engo.Point, len(first.Entities))
+	for i, e := range first.Entities {
+		firstPoints[i] = e.SpaceComponent.Position
+	}
+
+	clearWorld()
+	second, err := LoadScene(bytes.NewReader(saved.Bytes()))
+	if err != nil {
+		t.Fatalf("LoadScene of a saved scene returned an error: %v", err)
+	}
+	var resaved bytes.Buffer
+	if err = SaveScene(&resaved); err != nil {
+		t.Fatalf("SaveScene returned an error: %v", err)
+	}
+
+	// saved in the editor's coordinates, the way it was loaded
+	var file struct {
+		Gravity struct{ Y float64 }
+		Body    []struct {
+			Position json.RawMessage
+			Angle    float64
+		}
+	}
+	var crate struct{ X, Y float64 }
+	if err = json.Unmarshal(saved.Bytes(), &file); err != nil {
+		t.Fatalf("saved scene is not json: %v", err)
+	}
+	if err = json.Unmarshal(file.Body[1].Position, &crate); err != nil {
+		t.Fatalf("saved crate position is not json: %v", err)
+	}
+	if file.Gravity.Y != -10 {
+		t.Errorf("gravity was not saved in the editor's coordinates, got: %v", file.Gravity.Y)
+	}
+	if crate.X != 1 || crate.Y != 2 || file.Body[1].Angle != 0.5 {
+		t.Errorf("crate was not saved in the editor's coordinates, got: %+v at %v", crate, file.Body[1].Angle)
+	}
+
+	if saved.String() != resaved.String() {
+		t.Errorf("saving a loaded scene did not give the same scene, first:\n%s\nsecond:\n%s", saved.String(), resaved.String())
+	}
+
+	if len(second.Entities) != len(first.Entities) {
+		t.Fatalf("round trip changed the number of entities, want: %d, got: %d", len(first.Entities), len(second.Entities))
+	}
+	for i, e := range second.Entities {
+		if e.Name != first.Entities[i].Name {
+			t.Errorf("round trip changed entity %d name, want: %s, got: %s", i, first.Entities[i].Name, e.Name)
+		}
+		if e.SpaceComponent.Position != firstPoints[i] {
+			t.Errorf("round trip moved entity %d, want: %v, got: %v", i, firstPoints[i], e.SpaceComponent.Position)
+		}
+		if e.Body.GetMass() != first.Entities[i].Body.GetMass() {
+			t.Errorf("round trip changed entity %d mass, want: %v, got: %v", i, first.Entities[i].Body.GetMass(), e.Body.GetMass())
+		}
+	}
+}
+
+func TestSaveSceneDestroyedBody(t *testing.T) {
+	newTestWorld(t, 0)
+
+	scene, err := LoadScene(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("LoadScene returned an error: %v", err)
+	}
+	scene.Entities[1].DestroyBody()
+	removeBodies()
+
+	var saved bytes.Buffer
+	if err = SaveScene(&saved); err != nil {
+		t.Fatalf("SaveScene returned an error: %v", err)
+	}
+	clearWorld()
+	reloaded, err := LoadScene(&saved)
+	if err != nil {
+		t.Fatalf("LoadScene returned an error: %v", err)
+	}
+	if len(reloaded.Entities) != 3 {
+		t.Errorf("wrong number of entities, want: %d, got: %d", 3, len(reloaded.Entities))
+	}
+	if len(reloaded.Images) != 1 {
+		t.Errorf("image of the destroyed body was saved, want: %d images, got: %d", 1, len(reloaded.Images))
+	}
+}
+
+func TestLoadSceneErrors(t *testing.T) {
+	newTestWorld(t, 0)
+
+	bad := []string{
+		`{"body": [{"type": 2, "fixture": [{}]}]}`,
+		`{"body": [{"type": 2}], "joint": [{"type": "gear", "bodyA": 0, "bodyB": 0}]}`,
+		`{"body": [{"type": 2}], "joint": [{"type": "weld", "bodyA": 0, "bodyB": 4}]}`,
+		`{"body": [{"type": 2, "fixture": [{"polygon": {"vertices": {"x": [0, 1], "y": [0]}}}]}]}`,
+		`not json`,
+		`{"gravity": {"x": 0, "y": -10}, "body": [{"type": 2}, {"type": 0}], "joint": [{"type": "weld", "bodyA": 0, "bodyB": 1}, {"type": "weld", "bodyA": 0, "bodyB": 4}]}`,
+		`{"body": [{"type": 2}], "image": [{"file": "a.png", "body": 3}]}`,
+	}
+	for i, s := range bad {
+		if _, err := LoadScene(strings.NewReader(s)); err == nil {
+			t.Errorf("LoadScene did not return an error for bad scene %d", i)
+		}
+		if World.GetBodyCount() != 0 || World.GetJointCount() != 0 {
+			t.Errorf("bad scene %d was left partly loaded", i)
+		}
+		if g := World.GetGravity(); g.X != 0 || g.Y != 0 {
+			t.Errorf("bad scene %d changed the gravity to %v", i, g)
+		}
+	}
+}
+
+func TestUnloadScene(t *testing.T) {
+	newTestWorld(t, 0)
+
+	first, err := LoadScene(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("LoadScene returned an error: %v", err)
+	}
+	second, err := LoadScene(strings.NewReader(testScene))
+	if err != nil {
+		t.Fatalf("LoadScene returned an error: %v", err)
+	}
+	bodies := World.GetBodyCount()
+
+	// destroyed behind the package's back
+	World.DestroyBody(first.Entities[0].Body)
+	UnloadScene(first)
+	if want := bodies - len(first.Entities); World.GetBodyCount() != want {
+		t.Errorf("wrong number of bodies left, want: %d, got: %d", want, World.GetBodyCount())
+	}
+
+	World.DestroyBody(second.Entities[0].Body)
+	var saved bytes.Buffer
+	if err = SaveScene(&saved); err != nil {
+		t.Fatalf("SaveScene returned an error: %v", err)
+	}
+	clearWorld()
+	reloaded, err := LoadScene(&saved)
+	if err != nil {
+		t.Fatalf("LoadScene returned an error: %v", err)
+	}
+	if want := len(second.Entities) - 1; len(reloaded.Entities) != want {
+		t.Errorf("wrong number of entities saved, want: %d, got: %d", want, len(reloaded.Entities))
+	}
+	if len(reloaded.Images) != len(second.Images) {
+		t.Errorf("images of the unloaded scene were saved, want: %d images, got: %d", len(second.Images), len(reloaded.Images))
+	}
+}
@@ -0,0 +1,165 @@
+package engoBox2dSystem
+
+import (
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// SelectionChangedMessage is sent when the MouseSystem's box selection
+// changes.
+type SelectionChangedMessage struct {
+	// Selected is every body selected now, and Added and Removed are the
+	// changes.
+	Selected []*box2d.B2Body
+	Added    []*box2d.B2Body
+	Removed  []*box2d.B2Body
+}
+
+// Type implements the engo.Message interface
+func (SelectionChangedMessage) Type() string { return "SelectionChangedMessage" }
+
+// Selection returns the selected bodies, in the order they were selected.
+// Bodies are taken out of it when they're destroyed or their entity is removed
+// from the MouseSystem, with a SelectionChangedMessage.
+func (m *MouseSystem) Selection() []*box2d.B2Body {
+	return append([]*box2d.B2Body(nil), m.selection...)
+}
+
+// SelectionRect returns the box being dragged out, in world pixels, so it can be
+// drawn. ok is false if there's no box.
+func (m *MouseSystem) SelectionRect() (rect engo.AABB, ok bool) {
+	if !m.selecting {
+		return engo.AABB{}, false
+	}
+	return selectionRect(m.selectStart, engo.Point{X: m.mouseX, Y: m.mouseY}), true
+}
+
+func selectionRect(a, b engo.Point) engo.AABB {
+	rect := engo.AABB{Min: a, Max: b}
+	if rect.Min.X > rect.Max.X {
+		rect.Min.X, rect.Max.X = rect.Max.X, rect.Min.X
+	}
+	if rect.Min.Y > rect.Max.Y {
+		rect.Min.Y, rect.Max.Y = rect.Max.Y, rect.Min.Y
+	}
+	return rect
+}
+
+// updateSelection starts a box when the left button is pressed away from the
+// entities, and selects what's in it when it's released. The pointer's Button
+// is only checked on the Press and Release themselves, since it keeps the last
+// button pressed in between.
+func (m *MouseSystem) updateSelection(pressedEntity bool) {
+	switch m.pointer.Action {
+	case engo.Press:
+		if m.pointer.Button == engo.MouseButtonLeft && !pressedEntity {
+			m.selecting = true
+			m.selectStart = engo.Point{X: m.mouseX, Y: m.mouseY}
+		}
+	case engo.Release:
+		if m.selecting && m.pointer.Button == engo.MouseButtonLeft {
+			m.selecting = false
+			m.selectBodies(bodiesInRect(selectionRect(m.selectStart, engo.Point{X: m.mouseX, Y: m.mouseY})), m.pointer.Modifier)
+		}
+	}
+}
+
+// selectBodies changes the selection to the bodies. With shift they're added
+// to it, and with control they're toggled in it.
+func (m *MouseSystem) selectBodies(bodies []*box2d.B2Body, mod engo.Modifier) {
+	selected := make(map[*box2d.B2Body]bool)
+	for _, b := range m.selection {
+		selected[b] = true
+	}
+	inBox := make(map[*box2d.B2Body]bool)
+	for _, b := range bodies {
+		inBox[b] = true
+	}
+
+	var next, added, removed []*box2d.B2Body
+	for _, b := range m.selection {
+		switch {
+		case mod&engo.Control != 0 && inBox[b], mod&(engo.Control|engo.Shift) == 0 && !inBox[b]:
+			removed = append(removed, b)
+		default:
+			next = append(next, b)
+		}
+	}
+	for _, b := range bodies {
+		if !selected[b] {
+			next = append(next, b)
+			added = append(added, b)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	m.selection = next
+	engo.Mailbox.Dispatch(SelectionChangedMessage{
+		Selected: m.Selection(),
+		Added:    added,
+		Removed:  removed,
+	})
+}
+
+// dropDestroyed takes the bodies that aren't in the World anymore out of the
+// selection.
+func (m *MouseSystem) dropDestroyed() {
+	if len(m.selection) == 0 {
+		return
+	}
+	inWorld := make(map[*box2d.B2Body]bool)
+	for b := World.GetBodyList(); b != nil; b = b.GetNext() {
+		inWorld[b] = true
+	}
+	m.deselect(func(b *box2d.B2Body) bool { return !inWorld[b] })
+}
+
+// deselect takes the bodies out of the selection, such as ones that were
+// destroyed, and sends a SelectionChangedMessage if any were selected.
+func (m *MouseSystem) deselect(drop func(b *box2d.B2Body) bool) {
+	var next, removed []*box2d.B2Body
+	for _, b := range m.selection {
+		if drop(b) {
+			removed = append(removed, b)
+		} else {
+			next = append(next, b)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	m.selection = next
+	engo.Mailbox.Dispatch(SelectionChangedMessage{
+		Selected: m.Selection(),
+		Removed:  removed,
+	})
+}
+
+// bodiesInRect returns the bodies with a fixture overlapping the rect, in
+// pixels.
+func bodiesInRect(rect engo.AABB) []*box2d.B2Body {
+	if rect.Min.X == rect.Max.X || rect.Min.Y == rect.Max.Y {
+		return nil
+	}
+	min, max := Conv.ToBox2d2Vec(rect.Min), Conv.ToBox2d2Vec(rect.Max)
+	box := box2d.NewB2PolygonShape()
+	box.SetAsBoxFromCenterAndAngle((max.X-min.X)/2, (max.Y-min.Y)/2, box2d.B2Vec2{X: (min.X + max.X) / 2, Y: (min.Y + max.Y) / 2}, 0)
+	identity := box2d.MakeB2TransformByPositionAndRotation(box2d.B2Vec2{}, box2d.MakeB2RotFromAngle(0))
+
+	var bodies []*box2d.B2Body
+	found := make(map[*box2d.B2Body]bool)
+	World.QueryAABB(func(f *box2d.B2Fixture) bool {
+		b := f.GetBody()
+		shape := f.GetShape()
+		for i := 0; i < shape.GetChildCount() && !found[b]; i++ {
+			if box2d.B2TestOverlapShapes(box, 0, shape, i, identity, b.GetTransform()) {
+				found[b] = true
+				bodies = append(bodies, b)
+			}
+		}
+		return true
+	}, box2d.B2AABB{LowerBound: min, UpperBound: max})
+	return bodies
+}
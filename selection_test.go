@@ -0,0 +1,150 @@
+package engoBox2dSystem
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// dragSelect drags the mouse from one point to another with the modifier held.
+func dragSelect(from, to engo.Point, mod engo.Modifier) {
+	updateTime := float32(1.0 / 60.0)
+	engo.Input.Mouse.Modifer = mod
+	engo.Input.Mouse.Button = engo.MouseButtonLeft
+	engo.Input.Mouse.X, engo.Input.Mouse.Y = from.X, from.Y
+	engo.Input.Mouse.Action = engo.Press
+	sys.Update(updateTime)
+	engo.Input.Mouse.X, engo.Input.Mouse.Y = to.X, to.Y
+	engo.Input.Mouse.Action = engo.Move
+	sys.Update(updateTime)
+	engo.Input.Mouse.Action = engo.Release
+	sys.Update(updateTime)
+	engo.Input.Mouse.Action = engo.Neutral
+	engo.Input.Mouse.Modifer = 0
+}
+
+func TestMouseSystemBoxSelect(t *testing.T) {
+	newTestWorld(t, 0)
+	engo.Run(engo.RunOptions{
+		Width:        100,
+		Height:       100,
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &MouseTestScene{1})
+	sys.BoxSelect = true
+
+	var messages []SelectionChangedMessage
+	engo.Mailbox.Listen("SelectionChangedMessage", func(msg engo.Message) {
+		messages = append(messages, msg.(SelectionChangedMessage))
+	})
+	a := addStatic(engo.Point{X: 50, Y: 50}, 10, 10, 0)
+	b := addStatic(engo.Point{X: 70, Y: 50}, 10, 10, 0)
+	c := addStatic(engo.Point{X: 50, Y: 80}, 10, 10, 0)
+	check := func(step string, selected, added, removed []*box2d.B2Body) {
+		t.Helper()
+		if len(messages) != 1 {
+			t.Fatalf("%v: one message should be sent, got: %v", step, len(messages))
+		}
+		m := messages[0]
+		messages = nil
+		for _, test := range []struct {
+			name      string
+			want, got []*box2d.B2Body
+		}{{"selected", selected, m.Selected}, {"added", added, m.Added}, {"removed", removed, m.Removed}} {
+			if len(test.want) != len(test.got) {
+				t.Errorf("%v: %v should have %v bodies, got: %v", step, test.name, len(test.want), len(test.got))
+				continue
+			}
+			for i := range test.want {
+				if test.want[i] != test.got[i] {
+					t.Errorf("%v: %v body %v is wrong", step, test.name, i)
+				}
+			}
+		}
+	}
+
+	// the box touches a and b but not c
+	engo.Input.Mouse.X, engo.Input.Mouse.Y = 40, 40
+	engo.Input.Mouse.Button = engo.MouseButtonLeft
+	engo.Input.Mouse.Action = engo.Press
+	sys.Update(1.0 / 60.0)
+	engo.Input.Mouse.X, engo.Input.Mouse.Y = 66, 60
+	engo.Input.Mouse.Action = engo.Move
+	sys.Update(1.0 / 60.0)
+	if rect, ok := sys.SelectionRect(); !ok || rect != (engo.AABB{Min: engo.Point{X: 40, Y: 40}, Max: engo.Point{X: 66, Y: 60}}) {
+		t.Errorf("selection rect should be shown while dragging, got: %v, %v", rect, ok)
+	}
+	engo.Input.Mouse.Action = engo.Release
+	sys.Update(1.0 / 60.0)
+	if _, ok := sys.SelectionRect(); ok {
+		t.Errorf("selection rect should be gone after releasing")
+	}
+	check("select", []*box2d.B2Body{a, b}, []*box2d.B2Body{a, b}, nil)
+
+	// drawn backwards, with shift
+	dragSelect(engo.Point{X: 60, Y: 90}, engo.Point{X: 40, Y: 76}, engo.Shift)
+	check("shift", []*box2d.B2Body{a, b, c}, []*box2d.B2Body{c}, nil)
+
+	dragSelect(engo.Point{X: 40, Y: 40}, engo.Point{X: 60, Y: 90}, engo.Control)
+	check("control", []*box2d.B2Body{b}, nil, []*box2d.B2Body{a, c})
+
+	// selecting the same thing again changes nothing
+	dragSelect(engo.Point{X: 60, Y: 40}, engo.Point{X: 80, Y: 60}, 0)
+	if len(messages) != 0 {
+		t.Errorf("no message should be sent when the selection doesn't change")
+	}
+
+	// starting on the entity drags it instead
+	dragSelect(engo.Point{X: 5, Y: 5}, engo.Point{X: 90, Y: 90}, 0)
+	if len(messages) != 0 || len(sys.Selection()) != 1 {
+		t.Errorf("dragging an entity should not select")
+	}
+
+	// clicking on nothing clears it
+	dragSelect(engo.Point{X: 90, Y: 20}, engo.Point{X: 90, Y: 20}, 0)
+	check("clear", nil, nil, []*box2d.B2Body{b})
+
+	// the box only touches the chain's second edge
+	chain := box2d.MakeB2ChainShape()
+	chain.CreateChain([]box2d.B2Vec2{
+		Conv.ToBox2d2Vec(engo.Point{X: 85, Y: 70}),
+		Conv.ToBox2d2Vec(engo.Point{X: 95, Y: 70}),
+		Conv.ToBox2d2Vec(engo.Point{X: 95, Y: 95}),
+	}, 3)
+	d := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{}, 0, &chain, box2d.B2FixtureDef{})
+	dragSelect(engo.Point{X: 90, Y: 80}, engo.Point{X: 99, Y: 90}, 0)
+	check("chain", []*box2d.B2Body{d}, []*box2d.B2Body{d}, nil)
+
+	// a tracking entity gets the press, but isn't under it
+	sys.entities[0].Track = true
+	dragSelect(engo.Point{X: 40, Y: 40}, engo.Point{X: 66, Y: 60}, 0)
+	sys.entities[0].Track = false
+	check("track", []*box2d.B2Body{a, b}, []*box2d.B2Body{a, b}, []*box2d.B2Body{d})
+
+	// the right button doesn't select
+	engo.Input.Mouse.Button = engo.MouseButtonRight
+	engo.Input.Mouse.X, engo.Input.Mouse.Y = 40, 76
+	engo.Input.Mouse.Action = engo.Press
+	sys.Update(1.0 / 60.0)
+	engo.Input.Mouse.X, engo.Input.Mouse.Y = 60, 90
+	engo.Input.Mouse.Action = engo.Move
+	sys.Update(1.0 / 60.0)
+	engo.Input.Mouse.Action = engo.Release
+	sys.Update(1.0 / 60.0)
+	if _, ok := sys.SelectionRect(); ok || len(messages) != 0 {
+		t.Errorf("dragging with the right button should not select")
+	}
+	engo.Input.Mouse.Action = engo.Neutral
+
+	// destroyed bodies and removed entities leave the selection
+	(&Box2dComponent{Body: a}).DestroyBody()
+	sys.Update(1.0 / 60.0)
+	check("destroyed", []*box2d.B2Body{b}, nil, []*box2d.B2Body{a})
+	dragSelect(engo.Point{X: 80, Y: 60}, engo.Point{X: 0, Y: 0}, engo.Shift)
+	check("entity", []*box2d.B2Body{b, sys.entities[0].Body}, []*box2d.B2Body{sys.entities[0].Body}, nil)
+	entity := sys.entities[0]
+	sys.Remove(*entity.BasicEntity)
+	check("removed", []*box2d.B2Body{b}, nil, []*box2d.B2Body{entity.Body})
+}
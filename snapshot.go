@@ -0,0 +1,487 @@
+package engoBox2dSystem
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ByteArena/box2d"
+)
+
+// ErrSnapshotMismatch is returned by Restore when the World does not have the
+// same bodies, fixtures, and joints as it did when the snapshot was taken.
+var ErrSnapshotMismatch = errors.New("snapshot does not match the world")
+
+// WorldSnapshot is the state of every body, fixture, joint, and contact in the
+// World at one moment. It only holds exported, plain values so it can be
+// encoded with encoding/gob or encoding/json. Body user data that isn't an
+// entity ID must be registered with gob.Register to be gob encoded, and comes
+// back from json as the generic json types.
+type WorldSnapshot struct {
+	Gravity      box2d.B2Vec2
+	Flags        int
+	InvDt0       float64
+	StepComplete bool
+
+	Bodies   []BodySnapshot
+	Joints   []JointSnapshot
+	Contacts []ContactSnapshot
+	// Moved are the fixture proxies waiting for the broad-phase to look for
+	// new pairs.
+	Moved []ProxyRef
+}
+
+// BodySnapshot is the state of a single body. Flags holds the awake, active,
+// bullet, and sleep flags of the body.
+type BodySnapshot struct {
+	Type            uint8
+	Flags           uint32
+	Transform       box2d.B2Transform
+	Sweep           box2d.B2Sweep
+	LinearVelocity  box2d.B2Vec2
+	AngularVelocity float64
+	Force           box2d.B2Vec2
+	Torque          float64
+	SleepTime       float64
+
+	Mass, InvMass  float64
+	I, InvI        float64
+	LinearDamping  float64
+	AngularDamping float64
+	GravityScale   float64
+
+	// EntityID is the body's user data when it is set to an entity's ID, as the
+	// CollisionSystem does. Other user data is kept in UserData.
+	EntityID    uint64
+	HasEntityID bool
+	UserData    interface{}
+
+	Fixtures []FixtureSnapshot
+}
+
+// FixtureSnapshot is the state of a single fixture and its broad-phase
+// proxies.
+type FixtureSnapshot struct {
+	Density     float64
+	Friction    float64
+	Restitution float64
+	Sensor      bool
+	Filter      box2d.B2Filter
+
+	ProxyAABBs []box2d.B2AABB
+	FatAABBs   []box2d.B2AABB
+}
+
+// JointSnapshot is the state of a single joint. State holds the numeric fields
+// of the joint, such as motor settings and the accumulated impulses, in the
+// order they are declared.
+type JointSnapshot struct {
+	Type  uint8
+	State []float64
+}
+
+// ContactSnapshot is the state of a single contact, including the impulses
+// used to warm start the solver.
+type ContactSnapshot struct {
+	A, B         ProxyRef
+	Flags        uint32
+	Manifold     box2d.B2Manifold
+	TOICount     int
+	TOI          float64
+	Friction     float64
+	Restitution  float64
+	TangentSpeed float64
+}
+
+// ProxyRef identifies a child of a fixture by the index of its body and the
+// index of the fixture on the body, both in creation order.
+type ProxyRef struct {
+	Body, Fixture, Child int
+}
+
+// Snapshot captures the state of the World. Bodies, fixtures, joints, and
+// contacts are kept in the order box2d steps them, so restoring the snapshot
+// and stepping gives the same results as stepping the World did.
+func (b *PhysicsSystem) Snapshot() *WorldSnapshot {
+	bodies := worldBodies()
+	refs := make(map[*box2d.B2Fixture]ProxyRef)
+	tree := &World.M_contactManager.M_broadPhase.M_tree
+
+	s := &WorldSnapshot{
+		Gravity:      World.GetGravity(),
+		Flags:        World.M_flags,
+		InvDt0:       World.M_inv_dt0,
+		StepComplete: World.M_stepComplete,
+		Bodies:       make([]BodySnapshot, len(bodies)),
+	}
+
+	for i, body := range bodies {
+		bs := BodySnapshot{
+			Type:            body.M_type,
+			Flags:           body.M_flags,
+			Transform:       body.M_xf,
+			Sweep:           body.M_sweep,
+			LinearVelocity:  body.M_linearVelocity,
+			AngularVelocity: body.M_angularVelocity,
+			Force:           body.M_force,
+			Torque:          body.M_torque,
+			SleepTime:       body.M_sleepTime,
+			Mass:            body.M_mass,
+			InvMass:         body.M_invMass,
+			I:               body.M_I,
+			InvI:            body.M_invI,
+			LinearDamping:   body.M_linearDamping,
+			AngularDamping:  body.M_angularDamping,
+			GravityScale:    body.M_gravityScale,
+		}
+		if id, ok := body.GetUserData().(uint64); ok {
+			bs.EntityID, bs.HasEntityID = id, true
+		} else {
+			bs.UserData = body.GetUserData()
+		}
+		for j, f := range bodyFixtures(body) {
+			refs[f] = ProxyRef{Body: i, Fixture: j}
+			fs := FixtureSnapshot{
+				Density:     f.M_density,
+				Friction:    f.M_friction,
+				Restitution: f.M_restitution,
+				Sensor:      f.M_isSensor,
+				Filter:      f.M_filter,
+			}
+			for _, p := range f.M_proxies[:f.M_proxyCount] {
+				fs.ProxyAABBs = append(fs.ProxyAABBs, p.Aabb)
+				fs.FatAABBs = append(fs.FatAABBs, tree.GetFatAABB(p.ProxyId))
+			}
+			bs.Fixtures = append(bs.Fixtures, fs)
+		}
+		s.Bodies[i] = bs
+	}
+
+	for _, j := range worldJoints() {
+		s.Joints = append(s.Joints, JointSnapshot{Type: j.GetType(), State: jointState(j)})
+	}
+
+	for c := World.GetContactList(); c != nil; c = c.GetNext() {
+		a, b := refs[c.GetFixtureA()], refs[c.GetFixtureB()]
+		a.Child, b.Child = c.GetChildIndexA(), c.GetChildIndexB()
+		s.Contacts = append(s.Contacts, ContactSnapshot{
+			A:            a,
+			B:            b,
+			Flags:        c.GetFlags(),
+			Manifold:     *c.GetManifold(),
+			TOICount:     c.GetTOICount(),
+			TOI:          c.GetTOI(),
+			Friction:     c.GetFriction(),
+			Restitution:  c.GetRestitution(),
+			TangentSpeed: c.GetTangentSpeed(),
+		})
+	}
+
+	bp := &World.M_contactManager.M_broadPhase
+	for _, id := range bp.M_moveBuffer[:bp.M_moveCount] {
+		if id == box2d.E_nullProxy {
+			continue
+		}
+		p := bp.GetUserData(id).(*box2d.B2FixtureProxy)
+		ref := refs[p.Fixture]
+		ref.Child = p.ChildIndex
+		s.Moved = append(s.Moved, ref)
+	}
+
+	return s
+}
+
+// Restore sets the World back to the state in the snapshot, and moves the
+// SpaceComponents of the system's entities to match. The World must have the
+// same bodies, fixtures, and joints it had when the snapshot was taken,
+// otherwise ErrSnapshotMismatch is returned and nothing is changed.
+func (b *PhysicsSystem) Restore(s *WorldSnapshot) error {
+	if World.IsLocked() {
+		return errors.New("cannot restore a snapshot during a time step")
+	}
+	bodies := worldBodies()
+	joints := worldJoints()
+	if len(bodies) != len(s.Bodies) || len(joints) != len(s.Joints) {
+		return ErrSnapshotMismatch
+	}
+	fixtures := make([][]*box2d.B2Fixture, len(bodies))
+	for i, body := range bodies {
+		fixtures[i] = bodyFixtures(body)
+		if len(fixtures[i]) != len(s.Bodies[i].Fixtures) {
+			return ErrSnapshotMismatch
+		}
+	}
+	for i, j := range joints {
+		if j.GetType() != s.Joints[i].Type {
+			return ErrSnapshotMismatch
+		}
+	}
+	find := func(r ProxyRef) (*box2d.B2Fixture, error) {
+		if r.Body < 0 || r.Body >= len(fixtures) || r.Fixture < 0 || r.Fixture >= len(fixtures[r.Body]) {
+			return nil, ErrSnapshotMismatch
+		}
+		return fixtures[r.Body][r.Fixture], nil
+	}
+	for _, cs := range s.Contacts {
+		if _, err := find(cs.A); err != nil {
+			return err
+		}
+		if _, err := find(cs.B); err != nil {
+			return err
+		}
+	}
+
+	mgr := &World.M_contactManager
+	bp := &mgr.M_broadPhase
+
+	// Bodies that were switched on or off since the snapshot need their
+	// proxies back first.
+	for i, body := range bodies {
+		if body.M_type != s.Bodies[i].Type {
+			body.SetType(s.Bodies[i].Type)
+		}
+		active := s.Bodies[i].Flags&box2d.B2Body_Flags.E_activeFlag != 0
+		if body.IsActive() != active {
+			body.SetActive(active)
+		}
+	}
+
+	// Throw away the current contacts without telling anyone, they are
+	// replaced with the ones in the snapshot below.
+	listener := mgr.M_contactListener
+	mgr.M_contactListener = nil
+	for c := mgr.M_contactList; c != nil; {
+		next := c.GetNext()
+		mgr.Destroy(c)
+		c = next
+	}
+	mgr.M_contactListener = listener
+
+	for i, body := range bodies {
+		bs := s.Bodies[i]
+		body.M_flags = bs.Flags
+		body.M_xf = bs.Transform
+		body.M_sweep = bs.Sweep
+		body.M_linearVelocity = bs.LinearVelocity
+		body.M_angularVelocity = bs.AngularVelocity
+		body.M_force = bs.Force
+		body.M_torque = bs.Torque
+		body.M_sleepTime = bs.SleepTime
+		body.M_mass, body.M_invMass = bs.Mass, bs.InvMass
+		body.M_I, body.M_invI = bs.I, bs.InvI
+		body.M_linearDamping = bs.LinearDamping
+		body.M_angularDamping = bs.AngularDamping
+		body.M_gravityScale = bs.GravityScale
+		if bs.HasEntityID {
+			body.SetUserData(bs.EntityID)
+		} else {
+			body.SetUserData(bs.UserData)
+		}
+
+		for j, f := range fixtures[i] {
+			fs := bs.Fixtures[j]
+			f.M_density = fs.Density
+			f.M_friction = fs.Friction
+			f.M_restitution = fs.Restitution
+			f.M_isSensor = fs.Sensor
+			f.M_filter = fs.Filter
+			if len(fs.ProxyAABBs) != f.M_proxyCount || len(fs.FatAABBs) != f.M_proxyCount {
+				continue
+			}
+			for k := range f.M_proxies[:f.M_proxyCount] {
+				p := &f.M_proxies[k]
+				p.Aabb = fs.ProxyAABBs[k]
+				if bp.M_tree.M_nodes[p.ProxyId].Aabb != fs.FatAABBs[k] {
+					bp.M_tree.RemoveLeaf(p.ProxyId)
+					bp.M_tree.M_nodes[p.ProxyId].Aabb = fs.FatAABBs[k]
+					bp.M_tree.InsertLeaf(p.ProxyId)
+				}
+			}
+		}
+	}
+
+	for i, j := range joints {
+		setJointState(j, s.Joints[i].State)
+	}
+
+	// box2d adds new contacts to the front of the lists, so creating them in
+	// reverse gives the world and every body the same contact order as before.
+	for i := len(s.Contacts) - 1; i >= 0; i-- {
+		cs := s.Contacts[i]
+		fA, _ := find(cs.A)
+		fB, _ := find(cs.B)
+		c := box2d.B2ContactFactory(fA, cs.A.Child, fB, cs.B.Child)
+		if c == nil {
+			continue
+		}
+		linkContact(mgr, c)
+		c.SetFlags(cs.Flags)
+		*c.GetManifold() = cs.Manifold
+		c.SetTOICount(cs.TOICount)
+		c.SetTOI(cs.TOI)
+		c.SetFriction(cs.Friction)
+		c.SetRestitution(cs.Restitution)
+		c.SetTangentSpeed(cs.TangentSpeed)
+	}
+
+	bp.M_moveCount = 0
+	for _, r := range s.Moved {
+		f, err := find(r)
+		if err != nil || r.Child < 0 || r.Child >= f.M_proxyCount {
+			continue
+		}
+		bp.BufferMove(f.M_proxies[r.Child].ProxyId)
+	}
+
+	World.SetGravity(s.Gravity)
+	World.M_flags = s.Flags
+	World.M_inv_dt0 = s.InvDt0
+	World.M_stepComplete = s.StepComplete
+
+	for _, e := range b.entities {
+		e.SpaceComponent.Rotation = Conv.RadToDeg(e.Body.GetAngle())
+		e.SpaceComponent.SetCenter(Conv.ToEngoPoint(e.Body.GetPosition()))
+	}
+
+	return nil
+}
+
+// linkContact adds the contact to the front of the world's and its bodies'
+// contact lists, the same way box2d's contact manager does.
+func linkContact(mgr *box2d.B2ContactManager, c box2d.B2ContactInterface) {
+	bodyA := c.GetFixtureA().GetBody()
+	bodyB := c.GetFixtureB().GetBody()
+
+	c.SetPrev(nil)
+	c.SetNext(mgr.M_contactList)
+	if mgr.M_contactList != nil {
+		mgr.M_contactList.SetPrev(c)
+	}
+	mgr.M_contactList = c
+
+	nodeA := c.GetNodeA()
+	nodeA.Contact = c
+	nodeA.Other = bodyB
+	nodeA.Prev = nil
+	nodeA.Next = bodyA.M_contactList
+	if bodyA.M_contactList != nil {
+		bodyA.M_contactList.Prev = nodeA
+	}
+	bodyA.M_contactList = nodeA
+
+	nodeB := c.GetNodeB()
+	nodeB.Contact = c
+	nodeB.Other = bodyA
+	nodeB.Prev = nil
+	nodeB.Next = bodyB.M_contactList
+	if bodyB.M_contactList != nil {
+		bodyB.M_contactList.Prev = nodeB
+	}
+	bodyB.M_contactList = nodeB
+
+	mgr.M_contactCount++
+}
+
+// worldBodies returns the bodies in the World in the order they were created.
+func worldBodies() []*box2d.B2Body {
+	bodies := make([]*box2d.B2Body, World.GetBodyCount())
+	i := len(bodies) - 1
+	for b := World.GetBodyList(); b != nil && i >= 0; b = b.GetNext() {
+		bodies[i] = b
+		i--
+	}
+	return bodies
+}
+
+// bodyFixtures returns the fixtures of the body in the order they were created.
+func bodyFixtures(b *box2d.B2Body) []*box2d.B2Fixture {
+	fixtures := make([]*box2d.B2Fixture, b.M_fixtureCount)
+	i := len(fixtures) - 1
+	for f := b.GetFixtureList(); f != nil && i >= 0; f = f.GetNext() {
+		fixtures[i] = f
+		i--
+	}
+	return fixtures
+}
+
+// worldJoints returns the joints in the World in the order they were created.
+func worldJoints() []box2d.B2JointInterface {
+	joints := make([]box2d.B2JointInterface, World.GetJointCount())
+	i := len(joints) - 1
+	for j := World.GetJointList(); j != nil && i >= 0; j = j.GetNext() {
+		joints[i] = j
+		i--
+	}
+	return joints
+}
+
+// jointState flattens the numeric fields of a joint. Pointers, such as the
+// bodies and the embedded B2Joint, are left out.
+func jointState(j box2d.B2JointInterface) []float64 {
+	var state []float64
+	walkJoint(j, func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Float64:
+			state = append(state, v.Float())
+		case reflect.Bool:
+			if v.Bool() {
+				state = append(state, 1)
+			} else {
+				state = append(state, 0)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			state = append(state, float64(v.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			state = append(state, float64(v.Uint()))
+		}
+	})
+	return state
+}
+
+// setJointState sets the fields flattened by jointState.
+func setJointState(j box2d.B2JointInterface, state []float64) {
+	i := 0
+	walkJoint(j, func(v reflect.Value) {
+		if i >= len(state) {
+			return
+		}
+		switch v.Kind() {
+		case reflect.Float64:
+			v.SetFloat(state[i])
+		case reflect.Bool:
+			v.SetBool(state[i] != 0)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(int64(state[i]))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(uint64(state[i]))
+		default:
+			return
+		}
+		i++
+	})
+}
+
+func walkJoint(j box2d.B2JointInterface, fn func(reflect.Value)) {
+	v := reflect.ValueOf(j)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("unexpected joint type %T", j))
+	}
+	walkValue(v.Elem(), fn)
+}
+
+func walkValue(v reflect.Value, fn func(reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			walkValue(v.Field(i), fn)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkValue(v.Index(i), fn)
+		}
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+		// references to other parts of the world aren't part of the state
+	default:
+		fn(v)
+	}
+}
@@ -0,0 +1,211 @@
+package engoBox2dSystem
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+
+	"github.com/ByteArena/box2d"
+)
+
+// newTestStack adds a ground, a stack of boxes joined to a wheel, and a ball
+// to World and to phys. The boxes land on the ground within the first second.
+func newTestStack(phys *PhysicsSystem) []physicsEntity {
+	World.SetGravity(box2d.B2Vec2{X: 0, Y: 10})
+
+	var entities []physicsEntity
+	add := func(bodyType uint8, center engo.Point, w, h float32, circle bool) *box2d.B2Body {
+		basic := ecs.NewBasic()
+		space := &common.SpaceComponent{Width: w, Height: h}
+		space.SetCenter(center)
+		bodyDef := box2d.NewB2BodyDef()
+		bodyDef.Type = bodyType
+		bodyDef.Position = Conv.ToBox2d2Vec(space.Center())
+		body := World.CreateBody(bodyDef)
+		var shape box2d.B2ShapeInterface
+		if circle {
+			c := box2d.NewB2CircleShape()
+			c.M_radius = Conv.PxToMeters(w / 2)
+			shape = c
+		} else {
+			p := box2d.NewB2PolygonShape()
+			p.SetAsBox(Conv.PxToMeters(w/2), Conv.PxToMeters(h/2))
+			shape = p
+		}
+		body.CreateFixtureFromDef(&box2d.B2FixtureDef{
+			Shape:       shape,
+			Density:     1,
+			Friction:    0.5,
+			Restitution: 0.2,
+		})
+		e := physicsEntity{&basic, space, &Box2dComponent{Body: body}}
+		phys.Add(e.BasicEntity, e.SpaceComponent, e.Box2dComponent)
+		entities = append(entities, e)
+		return body
+	}
+
+	add(box2d.B2BodyType.B2_staticBody, engo.Point{X: 0, Y: 300}, 800, 20, false)
+	var prev *box2d.B2Body
+	for i := 0; i < 4; i++ {
+		box := add(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: float32(i * 3), Y: float32(250 - i*25)}, 20, 20, false)
+		if i == 0 {
+			box.SetLinearVelocity(box2d.B2Vec2{X: 1, Y: 0})
+		}
+		prev = box
+	}
+	wheel := add(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 30, Y: 140}, 16, 16, true)
+	jointDef := box2d.MakeB2RevoluteJointDef()
+	jointDef.Initialize(prev, wheel, Conv.ToBox2d2Vec(engo.Point{X: 30, Y: 140}))
+	jointDef.EnableMotor = true
+	jointDef.MotorSpeed = 3
+	jointDef.MaxMotorTorque = 5
+	World.CreateJoint(&jointDef)
+
+	ball := add(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: -100, Y: 280}, 10, 10, true)
+	ball.SetLinearVelocity(box2d.B2Vec2{X: 4, Y: 0})
+
+	return entities
+}
+
+type testBodyState struct {
+	xf          box2d.B2Transform
+	v           box2d.B2Vec2
+	w           float64
+	awake       bool
+	space       engo.Point
+	spaceRotate float32
+}
+
+func testBodyStates(entities []physicsEntity) []testBodyState {
+	states := make([]testBodyState, len(entities))
+	for i, e := range entities {
+		states[i] = testBodyState{
+			xf:          e.Body.GetTransform(),
+			v:           e.Body.GetLinearVelocity(),
+			w:           e.Body.GetAngularVelocity(),
+			awake:       e.Body.IsAwake(),
+			space:       e.SpaceComponent.Position,
+			spaceRotate: e.SpaceComponent.Rotation,
+		}
+	}
+	return states
+}
+
+func compareBodyStates(t *testing.T, want, got []testBodyState) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Fatalf("wrong number of bodies, want: %d, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("body %d state does not match\nwant: %+v\ngot:  %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	newTestWorld(t, 0)
+	updateTime := float32(1.0 / 60.0)
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+	entities := newTestStack(phys)
+
+	for i := 0; i < 40; i++ {
+		phys.Update(updateTime)
+	}
+	if World.GetContactCount() == 0 {
+		t.Fatal("test stack has no contacts to snapshot")
+	}
+	snap := phys.Snapshot()
+	atSnapshot := testBodyStates(entities)
+
+	for i := 0; i < 40; i++ {
+		phys.Update(updateTime)
+	}
+	want := testBodyStates(entities)
+
+	// mess with the world a bit more before restoring
+	for _, e := range entities {
+		e.Body.ApplyLinearImpulseToCenter(box2d.B2Vec2{X: 0, Y: -3}, true)
+	}
+	phys.Update(updateTime)
+
+	if err := phys.Restore(snap); err != nil {
+		t.Fatalf("Restore returned an error: %v", err)
+	}
+	compareBodyStates(t, atSnapshot, testBodyStates(entities))
+
+	for i := 0; i < 40; i++ {
+		phys.Update(updateTime)
+	}
+	compareBodyStates(t, want, testBodyStates(entities))
+}
+
+func TestSnapshotEncoding(t *testing.T) {
+	newTestWorld(t, 0)
+	updateTime := float32(1.0 / 60.0)
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+	entities := newTestStack(phys)
+	entities[1].Body.SetUserData(entities[1].ID())
+
+	for i := 0; i < 30; i++ {
+		phys.Update(updateTime)
+	}
+	snap := phys.Snapshot()
+	for i := 0; i < 30; i++ {
+		phys.Update(updateTime)
+	}
+	want := testBodyStates(entities)
+
+	var gobbed bytes.Buffer
+	if err := gob.NewEncoder(&gobbed).Encode(snap); err != nil {
+		t.Fatalf("gob encoding failed: %v", err)
+	}
+	jsoned, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("json encoding failed: %v", err)
+	}
+
+	fromGob := &WorldSnapshot{}
+	if err = gob.NewDecoder(&gobbed).Decode(fromGob); err != nil {
+		t.Fatalf("gob decoding failed: %v", err)
+	}
+	fromJSON := &WorldSnapshot{}
+	if err = json.Unmarshal(jsoned, fromJSON); err != nil {
+		t.Fatalf("json decoding failed: %v", err)
+	}
+
+	for name, s := range map[string]*WorldSnapshot{"gob": fromGob, "json": fromJSON} {
+		if err = phys.Restore(s); err != nil {
+			t.Fatalf("Restore of %s snapshot returned an error: %v", name, err)
+		}
+		if id, ok := entities[1].Body.GetUserData().(uint64); !ok || id != entities[1].ID() {
+			t.Errorf("%s snapshot did not restore the entity ID user data, got: %v", name, entities[1].Body.GetUserData())
+		}
+		for i := 0; i < 30; i++ {
+			phys.Update(updateTime)
+		}
+		compareBodyStates(t, want, testBodyStates(entities))
+	}
+}
+
+func TestRestoreMismatch(t *testing.T) {
+	newTestWorld(t, 0)
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+	entities := newTestStack(phys)
+	snap := phys.Snapshot()
+
+	entities[2].DestroyBody()
+	removeBodies()
+
+	if err := phys.Restore(snap); err != ErrSnapshotMismatch {
+		t.Errorf("Restore into a different world should fail, want: %v, got: %v", ErrSnapshotMismatch, err)
+	}
+}
@@ -0,0 +1,176 @@
+package engoBox2dSystem
+
+import (
+	"errors"
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// PhysicsStatsMessage is sent out after each Update of a PhysicsSystem with
+// Profile set.
+type PhysicsStatsMessage struct {
+	// SyncIn is how long it took to copy the SpaceComponents to the bodies
+	// and apply the forces of the fluids and effectors
+	SyncIn time.Duration
+	// Step is how long World.Step took
+	Step time.Duration
+	// SyncOut is how long it took to copy the bodies back to the SpaceComponents
+	SyncOut time.Duration
+
+	// AwakeBodies doesn't include static bodies, which never sleep
+	Bodies, AwakeBodies, Contacts, Joints, Proxies int
+}
+
+// Type implements the engo.Message interface
+func (PhysicsStatsMessage) Type() string { return "PhysicsStatsMessage" }
+
+// Total is the time spent syncing and stepping the World. It leaves out the
+// rest of the Update: taking the rollback snapshot, playing back or capturing
+// inputs, hashing the step, and removing bodies.
+func (m PhysicsStatsMessage) Total() time.Duration {
+	return m.SyncIn + m.Step + m.SyncOut
+}
+
+// TimingStats summarizes one of the timings over the stats window.
+type TimingStats struct {
+	Mean, P50, P95, P99, Max time.Duration
+}
+
+// PhysicsStatsSummary is the rolling summary of the last StatsWindow Updates.
+type PhysicsStatsSummary struct {
+	// Frames is how many Updates are in the summary
+	Frames int
+
+	SyncIn, Step, SyncOut, Total TimingStats
+
+	// Last is the stats from the latest Update
+	Last PhysicsStatsMessage
+}
+
+type physicsStatsWindow struct {
+	frames []PhysicsStatsMessage
+	next   int
+	full   bool
+}
+
+func (w *physicsStatsWindow) add(m PhysicsStatsMessage, size int) {
+	if len(w.frames) != size {
+		*w = physicsStatsWindow{frames: make([]PhysicsStatsMessage, size)}
+	}
+	w.frames[w.next] = m
+	w.next = (w.next + 1) % size
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+func (w *physicsStatsWindow) collected() []PhysicsStatsMessage {
+	if w.full {
+		return w.frames
+	}
+	return w.frames[:w.next]
+}
+
+// profile sends out the stats for the Update and adds them to the window.
+func (b *PhysicsSystem) profile(syncIn, step, syncOut time.Duration) {
+	m := PhysicsStatsMessage{
+		SyncIn:   syncIn,
+		Step:     step,
+		SyncOut:  syncOut,
+		Bodies:   World.GetBodyCount(),
+		Contacts: World.GetContactCount(),
+		Joints:   World.GetJointCount(),
+		Proxies:  World.M_contactManager.M_broadPhase.GetProxyCount(),
+	}
+	for bod := World.GetBodyList(); bod != nil; bod = bod.GetNext() {
+		if bod.IsAwake() && bod.GetType() != box2d.B2BodyType.B2_staticBody {
+			m.AwakeBodies++
+		}
+	}
+
+	size := b.StatsWindow
+	if size <= 0 {
+		size = 120
+	}
+	b.statsMu.Lock()
+	b.stats.add(m, size)
+	b.lastStats = m
+	b.statsMu.Unlock()
+	engo.Mailbox.Dispatch(m)
+}
+
+// StatsSummary returns the mean and percentiles of the timings over the last
+// StatsWindow Updates. Profile must be set for there to be anything in it. It's
+// safe to call from other goroutines, such as an HTTP handler.
+func (b *PhysicsSystem) StatsSummary() PhysicsStatsSummary {
+	b.statsMu.Lock()
+	frames := append([]PhysicsStatsMessage(nil), b.stats.collected()...)
+	s := PhysicsStatsSummary{Frames: len(frames), Last: b.lastStats}
+	b.statsMu.Unlock()
+	if len(frames) == 0 {
+		return s
+	}
+	timing := func(get func(PhysicsStatsMessage) time.Duration) TimingStats {
+		durations := make([]time.Duration, len(frames))
+		var sum time.Duration
+		for i, f := range frames {
+			durations[i] = get(f)
+			sum += durations[i]
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		percentile := func(p int) time.Duration {
+			return durations[(len(durations)-1)*p/100]
+		}
+		return TimingStats{
+			Mean: sum / time.Duration(len(durations)),
+			P50:  percentile(50),
+			P95:  percentile(95),
+			P99:  percentile(99),
+			Max:  durations[len(durations)-1],
+		}
+	}
+	s.SyncIn = timing(func(m PhysicsStatsMessage) time.Duration { return m.SyncIn })
+	s.Step = timing(func(m PhysicsStatsMessage) time.Duration { return m.Step })
+	s.SyncOut = timing(func(m PhysicsStatsMessage) time.Duration { return m.SyncOut })
+	s.Total = timing(PhysicsStatsMessage.Total)
+	return s
+}
+
+// ErrExpvarPublished is returned by PublishExpvar when something other than a
+// PhysicsSystem has already published the name.
+var ErrExpvarPublished = errors.New("expvar name is already published")
+
+// PublishExpvar publishes the StatsSummary with expvar under name, so it shows
+// up on /debug/vars. expvar names can only be published once per program, so
+// publishing a name a PhysicsSystem already has, such as after reloading a
+// scene, points it at this system instead.
+func (b *PhysicsSystem) PublishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if _, ok := expvarSystems[name]; ok {
+		expvarSystems[name] = b
+		return nil
+	}
+	if expvar.Get(name) != nil {
+		return ErrExpvarPublished
+	}
+	expvarSystems[name] = b
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		expvarMu.Lock()
+		sys := expvarSystems[name]
+		expvarMu.Unlock()
+		return sys.StatsSummary()
+	}))
+	return nil
+}
+
+var (
+	expvarMu      sync.Mutex
+	expvarSystems = make(map[string]*PhysicsSystem)
+)
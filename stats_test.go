@@ -0,0 +1,128 @@
+package engoBox2dSystem
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/EngoEngine/engo"
+)
+
+func TestPhysicsStats(t *testing.T) {
+	newTestWorld(t, 0)
+
+	var msgs []PhysicsStatsMessage
+	newTestMailbox(t)
+	engo.Mailbox.Listen("PhysicsStatsMessage", func(m engo.Message) {
+		msgs = append(msgs, m.(PhysicsStatsMessage))
+	})
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3, StatsWindow: 10}
+	newTestStack(phys)
+	phys.Update(1.0 / 60.0)
+	if len(msgs) != 0 || phys.StatsSummary().Frames != 0 {
+		t.Fatalf("stats were kept without Profile set")
+	}
+
+	phys.Profile = true
+	for i := 0; i < 40; i++ {
+		phys.Update(1.0 / 60.0)
+	}
+	if len(msgs) != 40 {
+		t.Fatalf("wrong number of stats messages, want: %d, got: %d", 40, len(msgs))
+	}
+
+	last := msgs[len(msgs)-1]
+	if last.Bodies != 7 || last.Joints != 1 || last.Proxies != 7 {
+		t.Errorf("wrong counts, want: 7 bodies, 1 joint, 7 proxies, got: %+v", last)
+	}
+	if last.Contacts != World.GetContactCount() || last.Contacts == 0 {
+		t.Errorf("wrong contact count, want: %d, got: %d", World.GetContactCount(), last.Contacts)
+	}
+	if last.AwakeBodies != 6 {
+		t.Errorf("wrong awake count, want: %d, got: %d", 6, last.AwakeBodies)
+	}
+	if last.Step <= 0 {
+		t.Errorf("step was not timed, got: %v", last.Step)
+	}
+
+	s := phys.StatsSummary()
+	if s.Frames != 10 {
+		t.Errorf("summary should only cover the window, want: %d, got: %d", 10, s.Frames)
+	}
+	if s.Last != last {
+		t.Errorf("summary has the wrong last stats, want: %+v, got: %+v", last, s.Last)
+	}
+	var sum, max time.Duration
+	for _, m := range msgs[30:] {
+		sum += m.Step
+		if m.Step > max {
+			max = m.Step
+		}
+	}
+	if s.Step.Mean != sum/10 || s.Step.Max != max {
+		t.Errorf("wrong step summary, want mean %v max %v, got: %+v", sum/10, max, s.Step)
+	}
+	if !(s.Step.P50 <= s.Step.P95 && s.Step.P95 <= s.Step.P99 && s.Step.P99 <= s.Step.Max) {
+		t.Errorf("percentiles are out of order, got: %+v", s.Step)
+	}
+	if s.Total.Max < s.Step.Max {
+		t.Errorf("total should include the step, got: %v < %v", s.Total.Max, s.Step.Max)
+	}
+}
+
+func TestPhysicsStatsExpvar(t *testing.T) {
+	first := &PhysicsSystem{}
+	first.stats.add(PhysicsStatsMessage{Bodies: 1}, 10)
+	first.lastStats = PhysicsStatsMessage{Bodies: 1}
+	second := &PhysicsSystem{}
+	second.stats.add(PhysicsStatsMessage{Bodies: 2}, 10)
+	second.lastStats = PhysicsStatsMessage{Bodies: 2}
+
+	if err := first.PublishExpvar("engoBox2dSystemTestStats"); err != nil {
+		t.Fatalf("PublishExpvar returned an error: %v", err)
+	}
+	// publishing the same name twice would panic without the system swapping
+	if err := second.PublishExpvar("engoBox2dSystemTestStats"); err != nil {
+		t.Fatalf("PublishExpvar returned an error for a name it published: %v", err)
+	}
+
+	var s PhysicsStatsSummary
+	if err := json.Unmarshal([]byte(expvar.Get("engoBox2dSystemTestStats").String()), &s); err != nil {
+		t.Fatalf("expvar did not publish json: %v", err)
+	}
+	if s.Frames != 1 || s.Last.Bodies != 2 {
+		t.Errorf("expvar should show the last system published, got: %+v", s)
+	}
+}
+
+func TestPhysicsStatsExpvarTaken(t *testing.T) {
+	if expvar.Get("engoBox2dSystemTestTaken") == nil {
+		expvar.NewInt("engoBox2dSystemTestTaken")
+	}
+	if err := (&PhysicsSystem{}).PublishExpvar("engoBox2dSystemTestTaken"); err != ErrExpvarPublished {
+		t.Errorf("wrong error for a name published by something else, want: %v, got: %v", ErrExpvarPublished, err)
+	}
+}
+
+// StatsSummary is read from the expvar handler's goroutine while the game loop
+// updates. Run with -race.
+func TestPhysicsStatsConcurrent(t *testing.T) {
+	newTestWorld(t, 0)
+	newTestMailbox(t)
+
+	phys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3, Profile: true, StatsWindow: 10}
+	newTestStack(phys)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			phys.StatsSummary()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		phys.Update(1.0 / 60.0)
+	}
+	<-done
+}
@@ -0,0 +1,186 @@
+package engoBox2dSystem
+
+import (
+	"math"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// TopDownSystemPriority makes sure the friction is applied before the
+// PhysicsSystem steps.
+const TopDownSystemPriority = 50
+
+// TopDownComponent gives a body ground friction in a top-down game, where the
+// World has no gravity to make box2d's friction work. Each frame, impulses
+// against the body's sideways, forward, and spinning motion are applied, up to
+// the limits here multiplied by the friction of the surface the body is on.
+// The body's local x axis is forward.
+//
+// Impulses are in box2d units, kg m/s, so they depend on the body's mass. For
+// a car, use a large MaxLateralImpulse so it doesn't skid, and a small
+// MaxForwardImpulse so it rolls. For a crate, use the same for both.
+type TopDownComponent struct {
+	// MaxLateralImpulse is the most sideways speed that can be removed in a
+	// frame.
+	MaxLateralImpulse float64
+	// MaxForwardImpulse is the most forward speed that can be removed in a
+	// frame.
+	MaxForwardImpulse float64
+	// MaxAngularImpulse is the most spin that can be removed in a frame, in
+	// kg m^2/s.
+	MaxAngularImpulse float64
+
+	// Steering makes the body try to move at DesiredVelocity, in pixels per
+	// second, instead of only slowing down. MaxSteeringForce, in newtons, times
+	// the surface friction is how hard it can push to get there, which takes the
+	// place of the linear friction. Use it for characters.
+	Steering         bool
+	DesiredVelocity  engo.Point
+	MaxSteeringForce float64
+
+	// Friction is the friction of the surface the body was on last frame.
+	Friction float32
+}
+
+// FrictionZoneComponent sets the friction for top-down bodies inside the
+// sensor fixtures of its body, such as ice or mud.
+type FrictionZoneComponent struct {
+	// Friction multiplies the limits of the TopDownComponents in the zone.
+	Friction float32
+}
+
+type topDownEntity struct {
+	*ecs.BasicEntity
+	*TopDownComponent
+	*Box2dComponent
+}
+
+type frictionZoneEntity struct {
+	*ecs.BasicEntity
+	*FrictionZoneComponent
+	*Box2dComponent
+}
+
+// TopDownSystem applies ground friction and steering to entities with a
+// TopDownComponent. Friction zones can overlap; a body uses the zone that was
+// added last.
+type TopDownSystem struct {
+	// DefaultFriction is the friction outside any zone. Defaults to 1.
+	DefaultFriction float32
+	// NoDefaultFriction turns off the friction outside the zones.
+	NoDefaultFriction bool
+
+	entities []topDownEntity
+	zones    []frictionZoneEntity
+}
+
+// Priority implements the ecs.Prioritizer interface.
+func (t *TopDownSystem) Priority() int { return TopDownSystemPriority }
+
+// Add adds a top-down entity to the system.
+func (t *TopDownSystem) Add(basic *ecs.BasicEntity, topDown *TopDownComponent, box *Box2dComponent) {
+	t.entities = append(t.entities, topDownEntity{basic, topDown, box})
+}
+
+// AddZone adds a friction zone to the system. The fixtures of the zone's body
+// should be sensors.
+func (t *TopDownSystem) AddZone(basic *ecs.BasicEntity, zone *FrictionZoneComponent, box *Box2dComponent) {
+	t.zones = append(t.zones, frictionZoneEntity{basic, zone, box})
+}
+
+// Remove removes the entity or zone from the system.
+func (t *TopDownSystem) Remove(basic ecs.BasicEntity) {
+	idx := -1
+	for index, e := range t.entities {
+		if e.BasicEntity.ID() == basic.ID() {
+			idx = index
+			break
+		}
+	}
+	if idx >= 0 {
+		t.entities = append(t.entities[:idx], t.entities[idx+1:]...)
+	}
+	idx = -1
+	for index, z := range t.zones {
+		if z.BasicEntity.ID() == basic.ID() {
+			idx = index
+			break
+		}
+	}
+	if idx >= 0 {
+		t.zones = append(t.zones[:idx], t.zones[idx+1:]...)
+	}
+}
+
+// Update applies the friction and steering impulses.
+func (t *TopDownSystem) Update(dt float32) {
+	for _, e := range t.entities {
+		e.Friction = t.friction(e.Body)
+		if e.Friction > 0 {
+			t.apply(e, float64(e.Friction), float64(dt))
+		}
+	}
+}
+
+// friction finds the friction of the last added zone the body is in.
+func (t *TopDownSystem) friction(body *box2d.B2Body) float32 {
+	zone := -1
+	for ce := body.GetContactList(); ce != nil; ce = ce.Next {
+		if !ce.Contact.IsTouching() {
+			continue
+		}
+		for i := len(t.zones) - 1; i > zone; i-- {
+			if t.zones[i].Body == ce.Other {
+				zone = i
+				break
+			}
+		}
+	}
+	if zone >= 0 {
+		return t.zones[zone].Friction
+	}
+	if t.NoDefaultFriction {
+		return 0
+	}
+	return defaultFloat(t.DefaultFriction, 1)
+}
+
+func (t *TopDownSystem) apply(e topDownEntity, friction, dt float64) {
+	body := e.Body
+	mass := body.GetMass()
+	v := body.GetLinearVelocity()
+
+	if e.Steering {
+		desired := Conv.ToBox2d2Vec(e.DesiredVelocity)
+		impulse := box2d.B2Vec2MulScalar(mass, box2d.B2Vec2Sub(desired, v))
+		body.ApplyLinearImpulseToCenter(clampImpulse(impulse, e.MaxSteeringForce*friction*dt), true)
+	} else {
+		forward := body.GetWorldVector(box2d.B2Vec2{X: 1, Y: 0})
+		lateral := body.GetWorldVector(box2d.B2Vec2{X: 0, Y: 1})
+		for _, axis := range []struct {
+			dir box2d.B2Vec2
+			max float64
+		}{{lateral, e.MaxLateralImpulse}, {forward, e.MaxForwardImpulse}} {
+			impulse := box2d.B2Vec2MulScalar(-mass*box2d.B2Vec2Dot(axis.dir, v), axis.dir)
+			body.ApplyLinearImpulseToCenter(clampImpulse(impulse, axis.max*friction), false)
+		}
+	}
+
+	angular := -body.GetInertia() * body.GetAngularVelocity()
+	limit := e.MaxAngularImpulse * friction
+	body.ApplyAngularImpulse(math.Max(-limit, math.Min(angular, limit)), false)
+}
+
+// clampImpulse shortens the impulse to at most max long.
+func clampImpulse(impulse box2d.B2Vec2, max float64) box2d.B2Vec2 {
+	if l := impulse.Length(); l > max {
+		if max <= 0 {
+			return box2d.B2Vec2{}
+		}
+		return box2d.B2Vec2MulScalar(max/l, impulse)
+	}
+	return impulse
+}
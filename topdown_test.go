@@ -0,0 +1,130 @@
+package engoBox2dSystem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+func TestTopDownFriction(t *testing.T) {
+	newTestWorld(t, 0)
+	sys := &TopDownSystem{}
+
+	var bodies []*box2d.B2Body
+	for i, topDown := range []*TopDownComponent{
+		{MaxLateralImpulse: 0.05, MaxForwardImpulse: 0.05, MaxAngularImpulse: 0.01},
+		{},
+		{MaxLateralImpulse: 1, MaxForwardImpulse: 0.001},
+	} {
+		// a 20x20 pixel box has a mass of 1 kg
+		body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: float32(i * 200)}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+		basic := ecs.NewBasic()
+		sys.Add(&basic, topDown, &Box2dComponent{Body: body})
+		bodies = append(bodies, body)
+	}
+	crate, slider, car := bodies[0], bodies[1], bodies[2]
+	for _, b := range []*box2d.B2Body{crate, slider, car} {
+		b.SetLinearVelocity(box2d.B2Vec2{X: 1, Y: 1})
+	}
+	crate.SetAngularVelocity(1)
+	slider.SetAngularVelocity(1)
+
+	// a 1 kg crate losing 0.05 kg m/s a frame stops within a second
+	stepFrames(60, sys.Update, stepWorld)
+	if v := crate.GetLinearVelocity(); v.Length() > 1e-9 || math.Abs(crate.GetAngularVelocity()) > 1e-9 {
+		t.Errorf("crate should have stopped, velocity: %v, spin: %v", v, crate.GetAngularVelocity())
+	}
+	if v := slider.GetLinearVelocity(); v != (box2d.B2Vec2{X: 1, Y: 1}) || slider.GetAngularVelocity() != 1 {
+		t.Errorf("body without friction should slide forever, velocity: %v, spin: %v", v, slider.GetAngularVelocity())
+	}
+
+	// the car stops skidding sideways right away, but keeps rolling forward
+	forward := car.GetWorldVector(box2d.B2Vec2{X: 1, Y: 0})
+	lateral := car.GetWorldVector(box2d.B2Vec2{X: 0, Y: 1})
+	v := car.GetLinearVelocity()
+	if s := box2d.B2Vec2Dot(v, lateral); math.Abs(s) > 1e-9 {
+		t.Errorf("car should not be skidding, sideways speed: %v", s)
+	}
+	if s := box2d.B2Vec2Dot(v, forward); s < 0.5 {
+		t.Errorf("car should still be rolling, forward speed: %v", s)
+	}
+}
+
+func TestTopDownZones(t *testing.T) {
+	newTestWorld(t, 0)
+	sys := &TopDownSystem{}
+
+	addZone := func(friction float32) {
+		body := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{}, 0, testBox(200, 200), box2d.B2FixtureDef{IsSensor: true})
+		basic := ecs.NewBasic()
+		sys.AddZone(&basic, &FrictionZoneComponent{Friction: friction}, &Box2dComponent{Body: body})
+	}
+	addZone(0.5)
+	// ice on top of the mud
+	addZone(0.1)
+
+	stopping := &TopDownComponent{MaxLateralImpulse: 0.05, MaxForwardImpulse: 0.05}
+	onIce := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	onIceBasic := ecs.NewBasic()
+	sys.Add(&onIceBasic, stopping, &Box2dComponent{Body: onIce})
+	outside := &TopDownComponent{MaxLateralImpulse: 0.05, MaxForwardImpulse: 0.05}
+	offIce := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 400}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	offIceBasic := ecs.NewBasic()
+	sys.Add(&offIceBasic, outside, &Box2dComponent{Body: offIce})
+	for _, b := range []*box2d.B2Body{onIce, offIce} {
+		b.SetLinearVelocity(box2d.B2Vec2{X: 0.2, Y: 0})
+	}
+
+	// contacts with the zones are found in the first step
+	stepFrames(2, sys.Update, stepWorld)
+	if stopping.Friction != 0.1 {
+		t.Errorf("body should use the last zone added, want: %v, got: %v", 0.1, stopping.Friction)
+	}
+	if outside.Friction != 1 {
+		t.Errorf("body outside the zones should use the default friction, want: %v, got: %v", 1, outside.Friction)
+	}
+	if onIce.GetLinearVelocity().X <= offIce.GetLinearVelocity().X {
+		t.Errorf("body on ice should slow down slower, ice: %v, ground: %v", onIce.GetLinearVelocity(), offIce.GetLinearVelocity())
+	}
+
+	sys.NoDefaultFriction = true
+	offIce.SetLinearVelocity(box2d.B2Vec2{X: 0.2, Y: 0})
+	stepFrames(2, sys.Update, stepWorld)
+	if v := offIce.GetLinearVelocity().X; v != 0.2 {
+		t.Errorf("no default friction should not slow the body, got: %v", v)
+	}
+}
+
+func TestTopDownSteering(t *testing.T) {
+	newTestWorld(t, 0)
+	sys := &TopDownSystem{}
+
+	walker := &TopDownComponent{
+		Steering:         true,
+		DesiredVelocity:  engo.Point{X: 100, Y: 0},
+		MaxSteeringForce: 20,
+	}
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: 0, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	basic := ecs.NewBasic()
+	sys.Add(&basic, walker, &Box2dComponent{Body: body})
+
+	// 5 m/s at 20 m/s^2 takes a quarter second
+	stepFrames(6, sys.Update, stepWorld)
+	if v := body.GetLinearVelocity().X; v >= 5 || v <= 0 {
+		t.Errorf("walker should still be speeding up, got: %v", v)
+	}
+	stepFrames(12, sys.Update, stepWorld)
+	if v := body.GetLinearVelocity(); math.Abs(v.X-5) > 1e-9 || math.Abs(v.Y) > 1e-9 {
+		t.Errorf("walker should be at the desired velocity, want: (5, 0), got: %v", v)
+	}
+
+	walker.DesiredVelocity = engo.Point{}
+	stepFrames(18, sys.Update, stepWorld)
+	if v := body.GetLinearVelocity(); v.Length() > 1e-9 {
+		t.Errorf("walker should have stopped, got: %v", v)
+	}
+}
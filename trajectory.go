@@ -0,0 +1,189 @@
+package engoBox2dSystem
+
+import (
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+// TrajectoryHit is the first thing a predicted body touches.
+type TrajectoryHit struct {
+	// Step is the index of the first point after the hit.
+	Step int
+	// Body is the static body that was hit, in the World. EntityID is the ID of
+	// its entity, if it was added to the CollisionSystem.
+	Body        *box2d.B2Body
+	EntityID    uint64
+	HasEntityID bool
+	// Point is where the body hit, and Normal points from the body into what
+	// it hit.
+	Point  engo.Point
+	Normal engo.Point
+}
+
+// PredictTrajectory predicts the path of the body if it were launched with the
+// velocity, in pixels per second, for drawing aiming guides. It's simulated
+// against a copy of the World's static bodies, using the World's gravity, the
+// body's damping, and the system's effectors and fluids, so the World isn't
+// touched. Dynamic and kinematic bodies are left out.
+//
+// The position of the body's origin, in pixels, is returned for the start and
+// after each step, along with the first hit, or nil if nothing was hit. The
+// body bounces off what it hits, so the path keeps going after it.
+func (b *PhysicsSystem) PredictTrajectory(body *box2d.B2Body, velocity engo.Point, steps int, dt float32) ([]engo.Point, *TrajectoryHit) {
+	def := box2d.MakeB2BodyDef()
+	def.Type = box2d.B2BodyType.B2_dynamicBody
+	def.Position = body.GetPosition()
+	def.Angle = body.GetAngle()
+	def.AngularVelocity = body.GetAngularVelocity()
+	def.LinearDamping = body.GetLinearDamping()
+	def.AngularDamping = body.GetAngularDamping()
+	def.GravityScale = body.GetGravityScale()
+	def.FixedRotation = body.IsFixedRotation()
+	def.Bullet = body.IsBullet()
+	var fixtures []box2d.B2FixtureDef
+	for _, f := range bodyFixtures(body) {
+		fixtures = append(fixtures, fixtureDef(f))
+	}
+	return b.predict(def, fixtures, velocity, steps, dt, ignoringEffectors[body])
+}
+
+// PredictShapeTrajectory is like PredictTrajectory, for a body that hasn't been
+// made yet, with one fixture of the shape and density starting at the position,
+// in pixels.
+func (b *PhysicsSystem) PredictShapeTrajectory(shape box2d.B2ShapeInterface, density float64, position, velocity engo.Point, steps int, dt float32) ([]engo.Point, *TrajectoryHit) {
+	def := box2d.MakeB2BodyDef()
+	def.Type = box2d.B2BodyType.B2_dynamicBody
+	def.Position = Conv.ToBox2d2Vec(position)
+	fixture := box2d.MakeB2FixtureDef()
+	fixture.Shape = shape
+	fixture.Density = density
+	return b.predict(def, []box2d.B2FixtureDef{fixture}, velocity, steps, dt, false)
+}
+
+func (b *PhysicsSystem) predict(def box2d.B2BodyDef, fixtures []box2d.B2FixtureDef, velocity engo.Point, steps int, dt float32, noEffectors bool) ([]engo.Point, *TrajectoryHit) {
+	world := box2d.MakeB2World(World.GetGravity())
+	listener := &trajectoryListener{statics: make(map[*box2d.B2Body]*box2d.B2Body)}
+	world.SetContactListener(listener)
+	for _, s := range worldBodies() {
+		if s.GetType() != box2d.B2BodyType.B2_staticBody {
+			continue
+		}
+		sdef := box2d.MakeB2BodyDef()
+		sdef.Position = s.GetPosition()
+		sdef.Angle = s.GetAngle()
+		copied := world.CreateBody(&sdef)
+		for _, f := range bodyFixtures(s) {
+			if !f.IsSensor() {
+				fd := fixtureDef(f)
+				copied.CreateFixtureFromDef(&fd)
+			}
+		}
+		listener.statics[copied] = s
+	}
+
+	def.LinearVelocity = Conv.ToBox2d2Vec(velocity)
+	body := world.CreateBody(&def)
+	for i := range fixtures {
+		body.CreateFixtureFromDef(&fixtures[i])
+	}
+	listener.body = body
+
+	points := []engo.Point{Conv.ToEngoPoint(body.GetPosition())}
+	for i := 0; i < steps; i++ {
+		if !noEffectors {
+			b.predictEffectors(body)
+		}
+		b.predictFluids(body)
+		listener.step = i + 1
+		world.Step(float64(dt), b.VelocityIterations, b.PositionIterations)
+		points = append(points, Conv.ToEngoPoint(body.GetPosition()))
+	}
+	return points, listener.hit
+}
+
+// predictEffectors applies the effectors to the predicted body, which isn't in
+// the World, so overlaps are checked by hand.
+func (b *PhysicsSystem) predictEffectors(body *box2d.B2Body) {
+	for _, ef := range b.effectors {
+		for _, f := range bodyFixtures(body) {
+			if ef.affects(f) && overlapsBody(f, ef.Body) {
+				body.ApplyForceToCenter(ef.force(body), true)
+				break
+			}
+		}
+	}
+}
+
+// predictFluids applies the fluids to the predicted body.
+func (b *PhysicsSystem) predictFluids(body *box2d.B2Body) {
+	for _, fl := range b.fluids {
+		for _, fluid := range bodyFixtures(fl.Body) {
+			for _, f := range bodyFixtures(body) {
+				if box2d.B2TestOverlapShapes(fluid.GetShape(), 0, f.GetShape(), 0, fl.Body.GetTransform(), body.GetTransform()) {
+					fl.push(fluid, f)
+				}
+			}
+		}
+	}
+}
+
+// overlapsBody checks if the fixture overlaps any of the body's fixtures.
+func overlapsBody(f *box2d.B2Fixture, body *box2d.B2Body) bool {
+	for other := body.GetFixtureList(); other != nil; other = other.GetNext() {
+		if box2d.B2TestOverlapShapes(f.GetShape(), 0, other.GetShape(), 0, f.GetBody().GetTransform(), body.GetTransform()) {
+			return true
+		}
+	}
+	return false
+}
+
+// fixtureDef makes a definition of a fixture like f.
+func fixtureDef(f *box2d.B2Fixture) box2d.B2FixtureDef {
+	fd := box2d.MakeB2FixtureDef()
+	fd.Shape = f.GetShape()
+	fd.Density = f.GetDensity()
+	fd.Friction = f.GetFriction()
+	fd.Restitution = f.GetRestitution()
+	fd.Filter = f.GetFilterData()
+	fd.IsSensor = f.IsSensor()
+	return fd
+}
+
+// trajectoryListener finds the first hit of the predicted body.
+type trajectoryListener struct {
+	body    *box2d.B2Body
+	statics map[*box2d.B2Body]*box2d.B2Body
+	step    int
+	hit     *TrajectoryHit
+}
+
+func (l *trajectoryListener) BeginContact(contact box2d.B2ContactInterface) {}
+
+func (l *trajectoryListener) EndContact(contact box2d.B2ContactInterface) {}
+
+func (l *trajectoryListener) PreSolve(contact box2d.B2ContactInterface, oldManifold box2d.B2Manifold) {
+	if l.hit != nil {
+		return
+	}
+	other := contact.GetFixtureB().GetBody()
+	if other == l.body {
+		other = contact.GetFixtureA().GetBody()
+	}
+	var wm box2d.B2WorldManifold
+	contact.GetWorldManifold(&wm)
+	normal := wm.Normal
+	if contact.GetFixtureA().GetBody() != l.body {
+		normal = box2d.B2Vec2MulScalar(-1, normal)
+	}
+	l.hit = &TrajectoryHit{
+		Step:   l.step,
+		Body:   l.statics[other],
+		Point:  Conv.ToEngoPoint(wm.Points[0]),
+		Normal: engo.Point{X: float32(normal.X), Y: float32(normal.Y)},
+	}
+	l.hit.EntityID, l.hit.HasEntityID = l.hit.Body.GetUserData().(uint64)
+}
+
+func (l *trajectoryListener) PostSolve(contact box2d.B2ContactInterface, impulse *box2d.B2ContactImpulse) {
+}
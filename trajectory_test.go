@@ -0,0 +1,89 @@
+package engoBox2dSystem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+
+	"github.com/ByteArena/box2d"
+)
+
+func TestPredictTrajectory(t *testing.T) {
+	newTestWorld(t, 10)
+	ground := addStatic(engo.Point{X: 0, Y: 310}, 1000, 20, 0)
+	ground.SetUserData(uint64(3))
+	sys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+
+	shape := box2d.NewB2CircleShape()
+	shape.M_radius = Conv.PxToMeters(10)
+	ball := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{}, 0, shape, box2d.B2FixtureDef{Density: 1, Restitution: 0.5})
+	ball.SetLinearDamping(0.1)
+
+	points, hit := sys.PredictTrajectory(ball, engo.Point{X: 100, Y: -200}, 180, 1.0/60.0)
+	if len(points) != 181 || points[0] != (engo.Point{}) {
+		t.Fatalf("path should have the start and a point for each step, got: %v points from %v", len(points), points[0])
+	}
+	if World.GetBodyCount() != 2 || ball.GetPosition() != (box2d.B2Vec2{}) || ball.GetLinearVelocity() != (box2d.B2Vec2{}) {
+		t.Fatalf("prediction should not touch the World")
+	}
+	if hit == nil {
+		t.Fatalf("ball should hit the ground")
+	}
+	if hit.Body != ground || !hit.HasEntityID || hit.EntityID != 3 {
+		t.Errorf("ball should hit the ground entity, got: %+v", hit)
+	}
+	if math.Abs(float64(hit.Point.Y-300)) > 1 || hit.Normal != (engo.Point{X: 0, Y: 1}) {
+		t.Errorf("ball should hit the top of the ground, got: %v, normal %v", hit.Point, hit.Normal)
+	}
+
+	// with only static bodies around, the prediction is exact, bounces and all
+	ball.SetLinearVelocity(Conv.ToBox2d2Vec(engo.Point{X: 100, Y: -200}))
+	bounced := false
+	for i := 1; i < len(points); i++ {
+		sys.Update(1.0 / 60.0)
+		if got := Conv.ToEngoPoint(ball.GetPosition()); got != points[i] {
+			t.Fatalf("step %v, predicted: %v, got: %v", i, points[i], got)
+		}
+		if i == hit.Step && ball.GetLinearVelocity().Y >= 0 {
+			t.Errorf("ball should bounce in the step of the hit")
+		}
+		bounced = bounced || ball.GetLinearVelocity().Y < 0 && i > hit.Step
+	}
+	if !bounced {
+		t.Errorf("predicted path should bounce")
+	}
+}
+
+func TestPredictShapeTrajectory(t *testing.T) {
+	newTestWorld(t, 0)
+	sys := &PhysicsSystem{VelocityIterations: 8, PositionIterations: 3}
+	effector := addTestBody(box2d.B2BodyType.B2_staticBody, engo.Point{X: 0, Y: 0}, 0, testBox(400, 400), box2d.B2FixtureDef{IsSensor: true})
+	basic := ecs.NewBasic()
+	sys.AddEffector(&basic, &EffectorComponent{
+		Kind:         EffectorDirectional,
+		Strength:     10,
+		Acceleration: true,
+		Direction:    engo.Point{X: 0, Y: 1},
+	}, &Box2dComponent{Body: effector})
+
+	shape := box2d.NewB2PolygonShape()
+	shape.SetAsBox(0.5, 0.5)
+	points, hit := sys.PredictShapeTrajectory(shape, 1, engo.Point{X: -100, Y: 0}, engo.Point{X: 100, Y: 0}, 60, 1.0/60.0)
+	if hit != nil {
+		t.Errorf("nothing should be hit, got: %+v", hit)
+	}
+	// pushed down while in the effector
+	if end := points[60]; math.Abs(float64(end.X-0)) > 1e-3 || end.Y < 100 {
+		t.Errorf("path should be bent by the effector, got: %v", end)
+	}
+
+	body := addTestBody(box2d.B2BodyType.B2_dynamicBody, engo.Point{X: -100, Y: 0}, 0, testBox(20, 20), box2d.B2FixtureDef{Density: 1})
+	(&Box2dComponent{Body: body}).IgnoreEffectors(true)
+	points, _ = sys.PredictTrajectory(body, engo.Point{X: 100, Y: 0}, 60, 1.0/60.0)
+	if end := points[60]; end.Y != 0 {
+		t.Errorf("body ignoring effectors should go straight, got: %v", end)
+	}
+	(&Box2dComponent{Body: body}).IgnoreEffectors(false)
+}